@@ -0,0 +1,288 @@
+package admin
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"workemailchecker/internal/validator"
+)
+
+// Source is the list source name of entries managed through the store.
+const Source = "admin"
+
+// Audit actions.
+const (
+	ActionAdd    = "add"
+	ActionRemove = "remove"
+	ActionImport = "import"
+)
+
+var (
+	ErrNotFound      = errors.New("entry not found")
+	ErrInvalidDomain = errors.New("invalid domain")
+)
+
+type Entry struct {
+	Domain          string `json:"domain"`
+	CorporateDomain string `json:"corporate_domain,omitempty"`
+}
+
+type AuditRecord struct {
+	Time    time.Time          `json:"time"`
+	Actor   string             `json:"actor"`
+	Action  string             `json:"action"`
+	List    validator.ListKind `json:"list"`
+	Domains []string           `json:"domains"`
+	Removed []string           `json:"removed,omitempty"` // entries dropped by a replacing import
+	Replace bool               `json:"replace,omitempty"`
+}
+
+// Store keeps the domain list entries added at runtime. Every change is
+// appended to dir/audit.ndjson and saved in dir/domains.json before it is
+// applied to the validator as the "admin" source.
+type Store struct {
+	dir string
+	v   *validator.Validator
+
+	mu    sync.Mutex
+	lists map[validator.ListKind]map[string]string
+}
+
+func NewStore(dir string, v *validator.Validator) (*Store, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create admin dir: %w", err)
+	}
+	s := &Store{dir: dir, v: v, lists: make(map[validator.ListKind]map[string]string)}
+	b, err := os.ReadFile(s.path("domains.json"))
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(b, &s.lists); err != nil {
+			return nil, fmt.Errorf("failed to read admin domains: %w", err)
+		}
+	case !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("failed to read admin domains: %w", err)
+	}
+	for kind, domains := range s.lists {
+		v.SetList(validator.DomainList{Kind: kind, Source: Source, Domains: domains})
+	}
+	return s, nil
+}
+
+// Add inserts or updates entries.
+func (s *Store) Add(actor string, kind validator.ListKind, entries []Entry) error {
+	return s.change(actor, ActionAdd, kind, false, func(domains map[string]string) ([]string, []string, error) {
+		changed, err := addEntries(domains, entries)
+		return changed, nil, err
+	})
+}
+
+// Import adds entries in one step, replacing the list's previous admin
+// entries when replace is set. The entries a replace drops are audited as
+// removed, and a replace that changes nothing is not recorded.
+func (s *Store) Import(actor string, kind validator.ListKind, entries []Entry, replace bool) error {
+	return s.change(actor, ActionImport, kind, replace, func(domains map[string]string) ([]string, []string, error) {
+		if !replace {
+			changed, err := addEntries(domains, entries)
+			return changed, nil, err
+		}
+		previous := maps.Clone(domains)
+		clear(domains)
+		changed, err := addEntries(domains, entries)
+		if err != nil {
+			return nil, nil, err
+		}
+		var removed []string
+		for d := range previous {
+			if _, ok := domains[d]; !ok {
+				removed = append(removed, d)
+			}
+		}
+		sort.Strings(removed)
+		return changed, removed, nil
+	})
+}
+
+// Remove deletes an entry added through the store. Entries from other
+// sources cannot be removed and report ErrNotFound.
+func (s *Store) Remove(actor string, kind validator.ListKind, domain string) error {
+	return s.change(actor, ActionRemove, kind, false, func(domains map[string]string) ([]string, []string, error) {
+		domain, err := normalizeDomain(domain)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := domains[domain]; !ok {
+			return nil, nil, ErrNotFound
+		}
+		delete(domains, domain)
+		return []string{domain}, nil, nil
+	})
+}
+
+// Audit returns up to limit of the most recent changes, newest first.
+func (s *Store) Audit(limit int) ([]AuditRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	f, err := os.Open(s.path("audit.ndjson"))
+	if errors.Is(err, os.ErrNotExist) {
+		return []AuditRecord{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var records []AuditRecord
+	sc := bufio.NewScanner(f)
+	sc.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		var rec AuditRecord
+		if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+			return nil, err
+		}
+		records = append(records, rec)
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+		records[i], records[j] = records[j], records[i]
+	}
+	if limit > 0 && len(records) > limit {
+		records = records[:limit]
+	}
+	return records, nil
+}
+
+// change applies fn to a copy of one list, records the change, saves the
+// list and publishes it to the validator. fn returns the domains it added
+// or removed by name and, for a replace, the ones it dropped. Nothing is
+// applied unless the change was audited, and the audit record is withdrawn
+// if saving fails.
+func (s *Store) change(actor, action string, kind validator.ListKind, replace bool, fn func(domains map[string]string) (changed, removed []string, err error)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	domains := make(map[string]string, len(s.lists[kind]))
+	for d, c := range s.lists[kind] {
+		domains[d] = c
+	}
+	changed, removed, err := fn(domains)
+	if err != nil {
+		return err
+	}
+	if replace && maps.Equal(domains, s.lists[kind]) {
+		return nil
+	}
+
+	lists := make(map[validator.ListKind]map[string]string, len(s.lists)+1)
+	for k, l := range s.lists {
+		lists[k] = l
+	}
+	lists[kind] = domains
+
+	rec := AuditRecord{
+		Time:    time.Now().UTC(),
+		Actor:   actor,
+		Action:  action,
+		List:    kind,
+		Domains: changed,
+		Removed: removed,
+		Replace: replace,
+	}
+	offset, err := s.appendAudit(rec)
+	if err != nil {
+		return fmt.Errorf("failed to audit change: %w", err)
+	}
+	if err := s.save(lists); err != nil {
+		if terr := os.Truncate(s.path("audit.ndjson"), offset); terr != nil {
+			return fmt.Errorf("%w; audit record not withdrawn: %v", err, terr)
+		}
+		return err
+	}
+	s.lists = lists
+	s.v.SetList(validator.DomainList{Kind: kind, Source: Source, Domains: domains})
+	return nil
+}
+
+// save writes the lists atomically; s.mu must be held.
+func (s *Store) save(lists map[validator.ListKind]map[string]string) error {
+	b, err := json.Marshal(lists)
+	if err != nil {
+		return err
+	}
+	tmp := s.path("domains.json.tmp")
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("failed to save admin domains: %w", err)
+	}
+	if err := os.Rename(tmp, s.path("domains.json")); err != nil {
+		return fmt.Errorf("failed to save admin domains: %w", err)
+	}
+	return nil
+}
+
+// appendAudit writes rec and returns the audit file's size before it, so
+// the record can be withdrawn by truncating; s.mu must be held.
+func (s *Store) appendAudit(rec AuditRecord) (int64, error) {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return 0, err
+	}
+	f, err := os.OpenFile(s.path("audit.ndjson"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return 0, err
+	}
+	_, err = f.Write(append(b, '\n'))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Truncate(f.Name(), info.Size())
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name)
+}
+
+func addEntries(domains map[string]string, entries []Entry) ([]string, error) {
+	changed := make([]string, 0, len(entries))
+	for _, e := range entries {
+		domain, err := normalizeDomain(e.Domain)
+		if err != nil {
+			return nil, err
+		}
+		canonical := ""
+		if strings.TrimSpace(e.CorporateDomain) != "" {
+			if canonical, err = normalizeDomain(e.CorporateDomain); err != nil {
+				return nil, err
+			}
+		}
+		domains[domain] = canonical
+		changed = append(changed, domain)
+	}
+	return changed, nil
+}
+
+func normalizeDomain(d string) (string, error) {
+	domain, err := validator.NormalizeDomain(d)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %v", ErrInvalidDomain, d, err)
+	}
+	return domain, nil
+}
@@ -0,0 +1,199 @@
+package admin
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"workemailchecker/internal/validator"
+)
+
+func newTestValidator() *validator.Validator {
+	opts := validator.DefaultOptions()
+	opts.Resolver = validator.NewFakeResolver().
+		AddMX("burner.example", "mx.burner.example.", 10).
+		AddMX("acme-labs.example", "mx.acme.example.", 10).
+		AddHost("mx.burner.example", "64.233.184.27").
+		AddHost("mx.acme.example", "64.233.184.26")
+	return validator.New(opts)
+}
+
+func TestStoreChangesAndReload(t *testing.T) {
+	dir := t.TempDir()
+	v := newTestValidator()
+	s, err := NewStore(dir, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Add("alice", validator.ListDisposable, []Entry{{Domain: "Burner.example"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Import("bob", validator.ListCorporate, []Entry{{Domain: "acme-labs.example", CorporateDomain: "acme.example"}}, false); err != nil {
+		t.Fatal(err)
+	}
+	res := v.Validate("a@burner.example")
+	if !res.IsDisposable || res.ListSource != Source {
+		t.Errorf("burner.example: disposable %v, list_source %q", res.IsDisposable, res.ListSource)
+	}
+	if got := v.Validate("a@acme-labs.example").CorporateDomain; got != "acme.example" {
+		t.Errorf("corporate_domain = %q, want acme.example", got)
+	}
+
+	// Built-in entries are not managed by the store
+	if err := s.Remove("alice", validator.ListDisposable, "yopmail.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("removing a built-in entry: %v, want ErrNotFound", err)
+	}
+
+	// A new store on the same directory re-applies the saved entries
+	v2 := newTestValidator()
+	s2, err := NewStore(dir, v2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !v2.Validate("a@burner.example").IsDisposable {
+		t.Error("saved entry not applied after reload")
+	}
+	if err := s2.Remove("carol", validator.ListDisposable, "burner.example"); err != nil {
+		t.Fatal(err)
+	}
+	if v2.Validate("a@burner.example").IsDisposable {
+		t.Error("removed entry still applied")
+	}
+	if got := adminEntries(v2, validator.ListDisposable); len(got) != 0 {
+		t.Errorf("entries after remove = %v", got)
+	}
+
+	records, err := s2.Audit(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ actor, action string }{{"carol", ActionRemove}, {"bob", ActionImport}, {"alice", ActionAdd}}
+	if len(records) != len(want) {
+		t.Fatalf("audit has %d records, want %d", len(records), len(want))
+	}
+	for i, w := range want {
+		if records[i].Actor != w.actor || records[i].Action != w.action {
+			t.Errorf("audit[%d] = %s %s, want %s %s", i, records[i].Actor, records[i].Action, w.actor, w.action)
+		}
+	}
+	if records[2].Domains[0] != "burner.example" {
+		t.Errorf("audit domains = %v", records[2].Domains)
+	}
+}
+
+func TestStoreImportReplace(t *testing.T) {
+	v := newTestValidator()
+	s, err := NewStore(t.TempDir(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Import("alice", validator.ListPersonalOverride, []Entry{{Domain: "a.example"}, {Domain: "b.example"}}, false); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Import("alice", validator.ListPersonalOverride, []Entry{{Domain: "c.example"}}, true); err != nil {
+		t.Fatal(err)
+	}
+	if got := adminEntries(v, validator.ListPersonalOverride); len(got) != 1 || got[0] != "c.example" {
+		t.Errorf("entries = %v, want only c.example", got)
+	}
+	// Replacing the list with what it already holds is not a change
+	if err := s.Import("bob", validator.ListPersonalOverride, []Entry{{Domain: "c.example"}}, true); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := s.Audit(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 2 {
+		t.Fatalf("audit has %d records, want 2", len(records))
+	}
+	if rec := records[0]; !rec.Replace || !reflect.DeepEqual(rec.Domains, []string{"c.example"}) || !reflect.DeepEqual(rec.Removed, []string{"a.example", "b.example"}) {
+		t.Errorf("replace audited as %+v, want c.example added and a.example, b.example removed", rec)
+	}
+}
+
+func TestStoreRejectsInvalidDomains(t *testing.T) {
+	v := newTestValidator()
+	s, err := NewStore(t.TempDir(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, entries := range [][]Entry{
+		{{Domain: "not a domain"}},
+		{{Domain: "ok.example"}, {Domain: ""}},
+		{{Domain: "labs.example", CorporateDomain: "acme..example"}},
+	} {
+		if err := s.Import("alice", validator.ListCorporate, entries, false); !errors.Is(err, ErrInvalidDomain) {
+			t.Errorf("Import(%v) = %v, want ErrInvalidDomain", entries, err)
+		}
+	}
+	if got := adminEntries(v, validator.ListCorporate); len(got) != 0 {
+		t.Errorf("entries after rejected imports = %v", got)
+	}
+	if err := s.Add("alice", validator.ListDisposable, []Entry{{Domain: "Bücher.example."}}); err != nil {
+		t.Fatal(err)
+	}
+	if got := adminEntries(v, validator.ListDisposable); len(got) != 1 || got[0] != "xn--bcher-kva.example" {
+		t.Errorf("entries = %v, want the A-label form", got)
+	}
+}
+
+func TestStoreAppliesOnlyAuditedChanges(t *testing.T) {
+	dir := t.TempDir()
+	v := newTestValidator()
+	s, err := NewStore(dir, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("alice", validator.ListDisposable, []Entry{{Domain: "a.example"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Saving fails: the audit record is withdrawn and nothing is applied
+	if err := os.Mkdir(filepath.Join(dir, "domains.json.tmp"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("alice", validator.ListDisposable, []Entry{{Domain: "b.example"}}); err == nil {
+		t.Fatal("Add succeeded although saving failed")
+	}
+	if records, err := s.Audit(0); err != nil || len(records) != 1 {
+		t.Errorf("audit after failed save = %v, %v; want the first change only", records, err)
+	}
+	os.Remove(filepath.Join(dir, "domains.json.tmp"))
+
+	// Auditing fails: nothing is saved or applied
+	if err := os.Rename(filepath.Join(dir, "audit.ndjson"), filepath.Join(dir, "audit.bak")); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "audit.ndjson"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Add("alice", validator.ListDisposable, []Entry{{Domain: "c.example"}}); err == nil {
+		t.Fatal("Add succeeded although auditing failed")
+	}
+	if got := adminEntries(v, validator.ListDisposable); len(got) != 1 || got[0] != "a.example" {
+		t.Errorf("entries = %v, want only a.example", got)
+	}
+	reloaded := newTestValidator()
+	if _, err := NewStore(dir, reloaded); err != nil {
+		t.Fatal(err)
+	}
+	if got := adminEntries(reloaded, validator.ListDisposable); len(got) != 1 || got[0] != "a.example" {
+		t.Errorf("saved entries = %v, want only a.example", got)
+	}
+}
+
+// adminEntries returns the domains the store applied to one of v's lists.
+func adminEntries(v *validator.Validator, kind validator.ListKind) []string {
+	var domains []string
+	for _, e := range v.Entries(kind) {
+		if e.Source == Source {
+			domains = append(domains, e.Domain)
+		}
+	}
+	return domains
+}
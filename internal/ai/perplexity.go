@@ -2,6 +2,7 @@ package ai
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"io"
@@ -48,6 +49,12 @@ type pplxResponse struct {
 }
 
 func CheckWithPerplexity(apiURL, apiKey, model, domain, quickSummary string) (*AIResult, error) {
+	return CheckWithPerplexityContext(context.Background(), apiURL, apiKey, model, domain, quickSummary)
+}
+
+// CheckWithPerplexityContext is CheckWithPerplexity with cancellation: the
+// upstream request is aborted as soon as ctx is done.
+func CheckWithPerplexityContext(ctx context.Context, apiURL, apiKey, model, domain, quickSummary string) (*AIResult, error) {
 	if apiKey == "" {
 		return nil, errors.New("missing api key")
 	}
@@ -91,7 +98,10 @@ func CheckWithPerplexity(apiURL, apiKey, model, domain, quickSummary string) (*A
 
 	b, _ := json.Marshal(reqBody)
 	log.Printf("Perplexity request model=%s domain=%s body=%s", model, domain, toUTF8JSON(b))
-	httpReq, _ := http.NewRequest("POST", apiURL, bytes.NewReader(b))
+	httpReq, err := http.NewRequestWithContext(ctx, "POST", apiURL, bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
 	httpReq.Header.Set("Content-Type", "application/json")
 	httpReq.Header.Set("Authorization", "Bearer "+apiKey)
 
@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"workemailchecker/internal/admin"
+	"workemailchecker/internal/validator"
+)
+
+type AdminDomainRequest struct {
+	List            string `json:"list"`
+	Domain          string `json:"domain"`
+	CorporateDomain string `json:"corporate_domain,omitempty"`
+}
+
+// AdminRemoveResponse answers a removal that left the domain listed by
+// another source.
+type AdminRemoveResponse struct {
+	Domain      string `json:"domain"`
+	StillListed string `json:"still_listed_by"`
+	Message     string `json:"message"`
+}
+
+type AdminImportRequest struct {
+	List    string        `json:"list"`
+	Entries []admin.Entry `json:"entries,omitempty"`
+	Domains []string      `json:"domains,omitempty"` // shorthand for entries without corporate_domain
+	Replace bool          `json:"replace,omitempty"` // drop the list's previous admin entries
+}
+
+type adminActorKey struct{}
+
+// AdminAuth checks bearer tokens; each token belongs to a named actor that
+// is recorded in the audit trail.
+type AdminAuth struct {
+	tokens map[string]string // token -> actor
+}
+
+// NewAdminAuth parses "actor:token" entries.
+func NewAdminAuth(entries []string) *AdminAuth {
+	a := &AdminAuth{tokens: make(map[string]string)}
+	for _, entry := range entries {
+		if actor, token, ok := strings.Cut(entry, ":"); ok && token != "" {
+			a.tokens[strings.TrimSpace(token)] = strings.TrimSpace(actor)
+		}
+	}
+	return a
+}
+
+func (a *AdminAuth) Enabled() bool {
+	return len(a.tokens) > 0
+}
+
+func (a *AdminAuth) Require(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		actor := ""
+		if ok {
+			for t, name := range a.tokens {
+				if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+					actor = name
+				}
+			}
+		}
+		if actor == "" {
+			w.Header().Set("Content-Type", "application/json")
+			w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Unauthorized"})
+			return
+		}
+		next(w, r.WithContext(context.WithValue(r.Context(), adminActorKey{}, actor)))
+	}
+}
+
+func adminActor(r *http.Request) string {
+	actor, _ := r.Context().Value(adminActorKey{}).(string)
+	return actor
+}
+
+// AdminDomainsHandler lists (GET), adds (POST) and removes (DELETE) list
+// entries. GET returns the effective list with the source of each entry;
+// DELETE only removes entries that were added through this API and answers
+// 409 for entries from other sources, which need an override instead.
+func AdminDomainsHandler(v *validator.Validator, store *admin.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method == "GET" {
+			kind, err := validator.ParseListKind(r.URL.Query().Get("list"))
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+				return
+			}
+			entries := v.Entries(kind)
+			w.WriteHeader(http.StatusOK)
+			json.NewEncoder(w).Encode(map[string]any{"list": kind, "count": len(entries), "entries": entries})
+			return
+		}
+		if r.Method != "POST" && r.Method != "DELETE" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		var req AdminDomainRequest
+		r.Body = http.MaxBytesReader(w, r.Body, 64<<10)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON format"})
+			return
+		}
+		kind, err := validator.ParseListKind(req.List)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		if strings.TrimSpace(req.Domain) == "" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Domain is required"})
+			return
+		}
+		domain, err := validator.NormalizeDomain(req.Domain)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid domain: " + err.Error()})
+			return
+		}
+
+		if r.Method == "DELETE" {
+			err := store.Remove(adminActor(r), kind, domain)
+			switch {
+			case errors.Is(err, admin.ErrNotFound):
+				if e, ok := v.Entry(kind, domain); ok {
+					w.WriteHeader(http.StatusConflict)
+					json.NewEncoder(w).Encode(ErrorResponse{Error: "Domain comes from the " + e.Source + " list and cannot be removed; add an override instead"})
+					return
+				}
+				w.WriteHeader(http.StatusNotFound)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Domain is not on the list"})
+			case err != nil:
+				writeStoreError(w, err)
+			default:
+				if e, ok := v.Entry(kind, domain); ok {
+					// The admin entry is gone, but the domain is still effective
+					w.WriteHeader(http.StatusOK)
+					json.NewEncoder(w).Encode(AdminRemoveResponse{
+						Domain:      domain,
+						StillListed: e.Source,
+						Message:     "Admin entry removed, but the domain is still listed by the " + e.Source + " list; add an override to reclassify it",
+					})
+					return
+				}
+				w.WriteHeader(http.StatusNoContent)
+			}
+			return
+		}
+
+		entry := admin.Entry{Domain: domain, CorporateDomain: req.CorporateDomain}
+		if err := store.Add(adminActor(r), kind, []admin.Entry{entry}); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+		json.NewEncoder(w).Encode(entry)
+	}
+}
+
+// AdminImportHandler adds many entries to one list in a single atomic
+// update.
+func AdminImportHandler(store *admin.Store, maxBytes int64) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		var req AdminImportRequest
+		r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON format"})
+			return
+		}
+		kind, err := validator.ParseListKind(req.List)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		entries := req.Entries
+		for _, d := range req.Domains {
+			entries = append(entries, admin.Entry{Domain: d})
+		}
+		if len(entries) == 0 && !req.Replace {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Entries are required"})
+			return
+		}
+
+		if err := store.Import(adminActor(r), kind, entries, req.Replace); err != nil {
+			writeStoreError(w, err)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]any{"list": kind, "imported": len(entries), "replace": req.Replace})
+	}
+}
+
+func writeStoreError(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, admin.ErrInvalidDomain) {
+		status = http.StatusBadRequest
+	}
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+}
+
+// AdminAuditHandler returns the most recent changes, newest first
+// (?limit=, default 100).
+func AdminAuditHandler(store *admin.Store) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		limit := 100
+		if s := r.URL.Query().Get("limit"); s != "" {
+			n, err := strconv.Atoi(s)
+			if err != nil || n <= 0 {
+				w.WriteHeader(http.StatusBadRequest)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "limit must be a positive integer"})
+				return
+			}
+			limit = n
+		}
+		records, err := store.Audit(limit)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: err.Error()})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(map[string]any{"count": len(records), "records": records})
+	}
+}
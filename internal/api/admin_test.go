@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"workemailchecker/internal/admin"
+	"workemailchecker/internal/validator"
+)
+
+func TestAdminAuthRequire(t *testing.T) {
+	auth := NewAdminAuth([]string{"alice:s3cret", "bob: other ", "broken", "nobody:"})
+	if !auth.Enabled() {
+		t.Fatal("auth with tokens is disabled")
+	}
+	if NewAdminAuth(nil).Enabled() {
+		t.Error("auth without tokens is enabled")
+	}
+	h := auth.Require(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(adminActor(r)))
+	})
+
+	tests := []struct {
+		header string
+		status int
+		actor  string
+	}{
+		{"Bearer s3cret", http.StatusOK, "alice"},
+		{"Bearer other", http.StatusOK, "bob"},
+		{"Bearer wrong", http.StatusUnauthorized, ""},
+		{"Bearer ", http.StatusUnauthorized, ""},
+		{"s3cret", http.StatusUnauthorized, ""},
+		{"Basic s3cret", http.StatusUnauthorized, ""},
+		{"", http.StatusUnauthorized, ""},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest("GET", "/api/admin/audit", nil)
+		if tt.header != "" {
+			req.Header.Set("Authorization", tt.header)
+		}
+		rec := httptest.NewRecorder()
+		h(rec, req)
+		if rec.Code != tt.status {
+			t.Errorf("%q: status %d, want %d", tt.header, rec.Code, tt.status)
+			continue
+		}
+		if tt.status == http.StatusOK && rec.Body.String() != tt.actor {
+			t.Errorf("%q: actor %q, want %q", tt.header, rec.Body.String(), tt.actor)
+		}
+		if tt.status == http.StatusUnauthorized && rec.Header().Get("WWW-Authenticate") == "" {
+			t.Errorf("%q: no WWW-Authenticate challenge", tt.header)
+		}
+	}
+}
+
+func TestAdminDomainsHandler(t *testing.T) {
+	v := validator.New(validator.DefaultOptions())
+	store, err := admin.NewStore(t.TempDir(), v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := AdminDomainsHandler(v, store)
+
+	tests := []struct {
+		method, body string
+		status       int
+	}{
+		{"POST", `{"list": "disposable", "domain": "Burner.example"}`, http.StatusCreated},
+		{"POST", `{"list": "disposable", "domain": "not a domain"}`, http.StatusBadRequest},
+		{"POST", `{"list": "corporate", "domain": "labs.example", "corporate_domain": "-acme.example"}`, http.StatusBadRequest},
+		{"DELETE", `{"list": "disposable", "domain": "yopmail.com"}`, http.StatusConflict},
+		{"DELETE", `{"list": "disposable", "domain": "unlisted.example"}`, http.StatusNotFound},
+		{"DELETE", `{"list": "disposable", "domain": "burner.example"}`, http.StatusNoContent},
+		{"POST", `{"list": "disposable", "domain": "yopmail.com"}`, http.StatusCreated},
+		{"DELETE", `{"list": "disposable", "domain": "yopmail.com"}`, http.StatusOK},
+		{"DELETE", `{"list": "disposable", "domain": "yopmail.com"}`, http.StatusConflict},
+	}
+	for _, tt := range tests {
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest(tt.method, "/api/admin/domains", strings.NewReader(tt.body)))
+		if rec.Code != tt.status {
+			t.Errorf("%s %s: status %d, want %d: %s", tt.method, tt.body, rec.Code, tt.status, rec.Body.String())
+		}
+		if rec.Code == http.StatusOK && !strings.Contains(rec.Body.String(), `"still_listed_by":"builtin"`) {
+			t.Errorf("%s %s: body %s does not name the remaining source", tt.method, tt.body, rec.Body.String())
+		}
+	}
+}
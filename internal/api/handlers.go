@@ -1,10 +1,15 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
+	"strconv"
 	"strings"
+	"time"
 
 	"workemailchecker/internal/ai"
 	"workemailchecker/internal/config"
@@ -12,15 +17,38 @@ import (
 )
 
 type EmailCheckRequest struct {
-	Email string `json:"email"`
-	Mode  string `json:"mode,omitempty"`
+	Email      string `json:"email"`
+	Mode       string `json:"mode,omitempty"`
+	RejectRole bool   `json:"reject_role,omitempty"`
+	Syntax     string `json:"syntax,omitempty"` // "strict" (default) or "lenient"
+	Debug      bool   `json:"debug,omitempty"`  // include the pipeline trace
+}
+
+type BatchCheckRequest struct {
+	Emails     []string `json:"emails"`
+	RejectRole bool     `json:"reject_role,omitempty"`
+	Syntax     string   `json:"syntax,omitempty"`
+	Debug      bool     `json:"debug,omitempty"`
+}
+
+type BatchCheckResponse struct {
+	Count   int                           `json:"count"`
+	Results []*validator.ValidationResult `json:"results"`
 }
 
 type ErrorResponse struct {
 	Error string `json:"error"`
 }
 
-func EmailCheckHandler(cfg *config.Config, aiLimiter *RateLimiter) http.HandlerFunc {
+// WriteTimeout is the server's write timeout.
+const WriteTimeout = 15 * time.Second
+
+// requestTimeout bounds all the work of one check or batch request, so the
+// response is written before WriteTimeout drops it. Stages it cuts off are
+// reported in timed_out like those that exceed their own budget.
+var requestTimeout = WriteTimeout - time.Second
+
+func EmailCheckHandler(cfg *config.Config, v *validator.Validator, aiLimiter *RateLimiter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
 		w.Header().Set("Access-Control-Allow-Origin", "*")
@@ -62,7 +90,21 @@ func EmailCheckHandler(cfg *config.Config, aiLimiter *RateLimiter) http.HandlerF
 			return
 		}
 
-		result := validator.ValidateEmail(req.Email)
+		syntax, ok := parseSyntaxMode(req.Syntax)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "syntax must be strict or lenient"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+		defer cancel()
+		opts := validator.CheckOptions{RejectRole: req.RejectRole, Syntax: syntax, Debug: debugRequested(r, req.Debug)}
+		result := v.Check(ctx, req.Email, opts)
+		if r.Context().Err() != nil {
+			// Client went away; nobody is waiting for the answer
+			return
+		}
 
 		if strings.EqualFold(req.Mode, "ai") {
 			cfg := cfg
@@ -71,6 +113,12 @@ func EmailCheckHandler(cfg *config.Config, aiLimiter *RateLimiter) http.HandlerF
 				json.NewEncoder(w).Encode(ErrorResponse{Error: "AI mode not enabled"})
 				return
 			}
+			if !result.SyntaxValid {
+				// Only a parsed address has a domain to ask about
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(result)
+				return
+			}
 			ip := getClientIP(r)
 			limiter := aiLimiter.getLimiter(ip)
 			if !limiter.Allow() {
@@ -78,27 +126,52 @@ func EmailCheckHandler(cfg *config.Config, aiLimiter *RateLimiter) http.HandlerF
 				w.Write([]byte(`{"error":"AI rate limit exceeded","retry_after":2}`))
 				return
 			}
-			domain := strings.ToLower(strings.Split(req.Email, "@")[1])
+			domain := result.DomainASCII
 			quick := "Fast check: valid=" + boolToStr(result.Valid) + ", personal=" + boolToStr(result.IsPersonal) + ", corporate=" + boolToStr(result.IsCorporate) + ", disposable=" + boolToStr(result.IsDisposable)
-			aiRes, err := ai.CheckWithPerplexity(cfg.PerplexityAPIURL, cfg.PerplexityAPIKey, cfg.PerplexityModel, domain, quick)
+			// Like the validator's stage budgets, zero means no AI deadline
+			start := time.Now()
+			var aiCtx context.Context
+			var aiCancel context.CancelFunc
+			if cfg.AITimeout > 0 {
+				aiCtx, aiCancel = context.WithTimeout(ctx, cfg.AITimeout)
+			} else {
+				aiCtx, aiCancel = context.WithCancel(ctx)
+			}
+			aiRes, err := ai.CheckWithPerplexityContext(aiCtx, cfg.PerplexityAPIURL, cfg.PerplexityAPIKey, cfg.PerplexityModel, domain, quick)
+			aiCancel()
+			if r.Context().Err() != nil {
+				return
+			}
+			if err != nil && errors.Is(err, context.DeadlineExceeded) {
+				result.TimedOut = append(result.TimedOut, validator.StageAI)
+				result.Message = "AI check timed out; fast check result returned"
+				result.AddTraceStep(start, validator.TraceStep{Stage: validator.StageAI, Input: domain, Outcome: "timed out"})
+				v.Score(result)
+				result.AddTraceStep(time.Now(), validator.TraceStep{Stage: "score", Answers: result.Reasons, Outcome: strconv.Itoa(result.RiskScore)})
+				w.WriteHeader(http.StatusOK)
+				json.NewEncoder(w).Encode(result)
+				return
+			}
 			if err != nil {
 				w.WriteHeader(http.StatusBadGateway)
 				json.NewEncoder(w).Encode(ErrorResponse{Error: "AI check failed"})
 				return
 			}
-			if aiRes.Verdict == "corporate" {
-				result.IsCorporate = true
-				result.IsPersonal = false
-				result.ProviderType = "corporate"
-				result.Message = "AI: corporate (confidence=" + fmt.Sprintf("%.2f", aiRes.Confidence) + ")"
-			} else if aiRes.Verdict == "personal" {
-				result.IsPersonal = true
-				result.IsCorporate = false
-				result.ProviderType = "personal"
-				result.Message = "AI: personal (confidence=" + fmt.Sprintf("%.2f", aiRes.Confidence) + ")"
+			verdict := fmt.Sprintf("AI: %s (confidence=%.2f)", aiRes.Verdict, aiRes.Confidence)
+			outcome := verdict + " applied"
+			if v.ApplyVerdict(result, opts, validator.RuleAI, aiRes.Verdict, aiRes.Confidence) {
+				result.Message = verdict
+			} else if aiRes.Verdict == "corporate" || aiRes.Verdict == "personal" {
+				// A list match or the disposable heuristic outranks the AI
+				result.Message += "; " + verdict + " not applied over " + result.ClassifiedBy
+				outcome = verdict + " not applied over " + result.ClassifiedBy
 			} else {
-				result.Message = "AI: unknown (confidence=" + fmt.Sprintf("%.2f", aiRes.Confidence) + ")"
+				result.Message = verdict
+				outcome = verdict
 			}
+			result.AddTraceStep(start, validator.TraceStep{Stage: validator.StageAI, Input: domain, Rule: result.ClassifiedBy,
+				Outcome: outcome + "; valid=" + boolToStr(result.Valid) + " type=" + result.ProviderType})
+			result.AddTraceStep(time.Now(), validator.TraceStep{Stage: "score", Answers: result.Reasons, Outcome: strconv.Itoa(result.RiskScore)})
 		}
 
 		w.WriteHeader(http.StatusOK)
@@ -106,6 +179,107 @@ func EmailCheckHandler(cfg *config.Config, aiLimiter *RateLimiter) http.HandlerF
 	}
 }
 
+func BatchCheckHandler(cfg *config.Config, v *validator.Validator, limiter *RateLimiter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		w.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+
+		if r.Method == "OPTIONS" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		if ct := r.Header.Get("Content-Type"); ct != "" && !strings.Contains(ct, "application/json") {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Content-Type must be application/json"})
+			return
+		}
+
+		// Roughly one maximum-length address per item plus JSON overhead
+		r.Body = http.MaxBytesReader(w, r.Body, int64(cfg.BatchMaxSize)*330+1024)
+
+		var req BatchCheckRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid JSON"})
+			return
+		}
+		if len(req.Emails) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Emails are required"})
+			return
+		}
+		if len(req.Emails) > cfg.BatchMaxSize {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("Batch exceeds maximum size of %d", cfg.BatchMaxSize)})
+			return
+		}
+
+		syntax, ok := parseSyntaxMode(req.Syntax)
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "syntax must be strict or lenient"})
+			return
+		}
+
+		cost := 1
+		if cfg.BatchRateLimitMode == "item" {
+			cost = len(req.Emails)
+		}
+		if retryAfter, ok := limiter.AllowN(r, cost); !ok {
+			w.WriteHeader(http.StatusTooManyRequests)
+			if retryAfter == 0 {
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Batch is larger than the rate limit burst"})
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]any{
+				"error":       "Rate limit exceeded. Please try again later.",
+				"retry_after": int(math.Ceil(retryAfter.Seconds())),
+			})
+			return
+		}
+
+		for i := range req.Emails {
+			req.Emails[i] = strings.TrimSpace(req.Emails[i])
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), requestTimeout)
+		defer cancel()
+		opts := validator.CheckOptions{RejectRole: req.RejectRole, Syntax: syntax, Debug: debugRequested(r, req.Debug)}
+		results := v.ValidateBatch(ctx, req.Emails, cfg.BatchWorkers, opts)
+		if r.Context().Err() != nil {
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(BatchCheckResponse{Count: len(results), Results: results})
+	}
+}
+
+// debugRequested accepts "debug": true in the body or ?debug=true.
+func debugRequested(r *http.Request, body bool) bool {
+	q, _ := strconv.ParseBool(r.URL.Query().Get("debug"))
+	return body || q
+}
+
+func parseSyntaxMode(s string) (validator.SyntaxMode, bool) {
+	switch validator.SyntaxMode(strings.ToLower(s)) {
+	case "", validator.SyntaxStrict:
+		return validator.SyntaxStrict, true
+	case validator.SyntaxLenient:
+		return validator.SyntaxLenient, true
+	}
+	return "", false
+}
+
 func boolToStr(b bool) string {
 	if b {
 		return "true"
@@ -113,11 +287,27 @@ func boolToStr(b bool) string {
 	return "false"
 }
 
-func HealthCheckHandler(w http.ResponseWriter, r *http.Request) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(map[string]string{
-		"status":  "healthy",
-		"service": "WorkEmailChecker",
-	})
+func HealthCheckHandler(dnsCache *validator.CachingResolver, lists ...*validator.RemoteList) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		resp := map[string]any{
+			"status":  "healthy",
+			"service": "WorkEmailChecker",
+		}
+		if dnsCache != nil {
+			resp["dns_cache"] = dnsCache.Stats()
+		}
+		if len(lists) > 0 {
+			statuses := make([]validator.RemoteListStatus, len(lists))
+			for i, l := range lists {
+				statuses[i] = l.Status()
+				if statuses[i].Degraded {
+					resp["status"] = "degraded"
+				}
+			}
+			resp["lists"] = statuses
+		}
+		json.NewEncoder(w).Encode(resp)
+	}
 }
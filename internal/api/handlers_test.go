@@ -0,0 +1,132 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"workemailchecker/internal/config"
+	"workemailchecker/internal/validator"
+)
+
+// aiStub answers every Perplexity request with the given verdict.
+func aiStub(t *testing.T, verdict string) *httptest.Server {
+	t.Helper()
+	content, _ := json.Marshal(map[string]any{"domain": "x", "verdict": verdict, "confidence": 0.9})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"choices": []any{map[string]any{"message": map[string]any{"content": string(content)}}},
+		})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestEmailCheckHandlerAIVerdict(t *testing.T) {
+	opts := validator.DefaultOptions()
+	opts.Resolver = validator.NewFakeResolver().
+		AddMX("acme.example", "mx.acme.example", 10).
+		AddHost("mx.acme.example", "64.233.184.26")
+	v := validator.New(opts)
+
+	tests := []struct {
+		email, verdict string
+		valid          bool
+		providerType   string
+		classifiedBy   string
+		reason         string
+	}{
+		// The disposable list outranks the AI
+		{"user@yopmail.com", "corporate", false, "disposable", validator.RuleDisposableList, validator.ReasonDisposableListMatch},
+		// The AI replaces the MX heuristic
+		{"user@acme.example", "personal", true, "personal", validator.RuleAI, validator.ReasonFreeProvider},
+	}
+	for _, tt := range tests {
+		cfg := &config.Config{EnableAICheck: true, PerplexityAPIKey: "key", PerplexityAPIURL: aiStub(t, tt.verdict).URL}
+		h := EmailCheckHandler(cfg, v, NewRateLimiter(10, 10))
+		rec := httptest.NewRecorder()
+		h(rec, httptest.NewRequest("POST", "/api/check", strings.NewReader(`{"email": "`+tt.email+`", "mode": "ai", "debug": true}`)))
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tt.email, rec.Code, rec.Body)
+		}
+		var res validator.ValidationResult
+		if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+			t.Fatal(err)
+		}
+		if res.Valid != tt.valid || res.ProviderType != tt.providerType || res.ClassifiedBy != tt.classifiedBy {
+			t.Errorf("%s: valid=%v provider_type=%s classified_by=%s, want %v %s %s",
+				tt.email, res.Valid, res.ProviderType, res.ClassifiedBy, tt.valid, tt.providerType, tt.classifiedBy)
+		}
+		if res.IsCorporate && (res.IsDisposable || res.IsPersonal) {
+			t.Errorf("%s: inconsistent flags %+v", tt.email, res)
+		}
+		if n := len(res.Trace); n < 2 || res.Trace[n-2].Stage != validator.StageAI || res.Trace[n-2].Rule != tt.classifiedBy {
+			t.Errorf("%s: trace does not end with the AI step: %+v", tt.email, res.Trace)
+		}
+		if !slices.Contains(res.Reasons, tt.reason) {
+			t.Errorf("%s: reasons %v, want %s", tt.email, res.Reasons, tt.reason)
+		}
+	}
+}
+
+func TestHealthCheckHandlerDegraded(t *testing.T) {
+	opts := validator.DefaultOptions()
+	opts.Resolver = validator.NewFakeResolver()
+	freeList := validator.NewRemoteList(validator.New(opts), validator.RemoteListOptions{
+		Kind:     validator.ListFree,
+		URL:      "http://127.0.0.1:0/domains.json",
+		Fallback: validator.FreeProvidersFallback(),
+	})
+	if err := freeList.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec := httptest.NewRecorder()
+	HealthCheckHandler(nil, freeList)(rec, httptest.NewRequest(http.MethodGet, "/api/health", nil))
+	var resp struct {
+		Status string                       `json:"status"`
+		Lists  []validator.RemoteListStatus `json:"lists"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || resp.Status != "degraded" || len(resp.Lists) != 1 || !resp.Lists[0].Degraded {
+		t.Errorf("health = %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// stallingResolver answers MX queries only when the caller gives up.
+type stallingResolver struct {
+	*validator.FakeResolver
+}
+
+func (stallingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestEmailCheckHandlerRequestTimeout(t *testing.T) {
+	defer func(d time.Duration) { requestTimeout = d }(requestTimeout)
+	requestTimeout = 50 * time.Millisecond
+
+	opts := validator.DefaultOptions()
+	opts.Resolver = stallingResolver{validator.NewFakeResolver()}
+	opts.Timeouts = validator.Timeouts{} // no stage budget of its own
+	h := EmailCheckHandler(&config.Config{}, validator.New(opts), NewRateLimiter(10, 10))
+
+	rec := httptest.NewRecorder()
+	h(rec, httptest.NewRequest("POST", "/api/check", strings.NewReader(`{"email": "jane@slow.example"}`)))
+	var res validator.ValidationResult
+	if err := json.NewDecoder(rec.Body).Decode(&res); err != nil {
+		t.Fatalf("status %d: %v", rec.Code, err)
+	}
+	if rec.Code != http.StatusOK || !slices.Contains(res.TimedOut, validator.StageDNS) {
+		t.Errorf("status %d timed_out %v, want 200 with %s", rec.Code, res.TimedOut, validator.StageDNS)
+	}
+}
@@ -0,0 +1,117 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"workemailchecker/internal/config"
+	"workemailchecker/internal/jobs"
+)
+
+// CreateJobHandler accepts an email list as JSON, CSV or newline-delimited
+// text (chosen by Content-Type or ?format=) and queues a bulk job.
+func CreateJobHandler(cfg *config.Config, m *jobs.Manager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		if r.Method != "POST" {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Method not allowed"})
+			return
+		}
+
+		format := strings.ToLower(r.URL.Query().Get("format"))
+		if format == "" {
+			format = jobs.FormatFromContentType(r.Header.Get("Content-Type"))
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, cfg.JobsMaxUploadBytes)
+		emails, err := jobs.ParseList(r.Body, format)
+		if err != nil {
+			var maxErr *http.MaxBytesError
+			if errors.As(err, &maxErr) {
+				w.WriteHeader(http.StatusRequestEntityTooLarge)
+				json.NewEncoder(w).Encode(ErrorResponse{Error: "Upload too large"})
+				return
+			}
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Invalid list: " + err.Error()})
+			return
+		}
+		if len(emails) == 0 {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Emails are required"})
+			return
+		}
+		if len(emails) > cfg.JobsMaxEmails {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: fmt.Sprintf("List exceeds maximum size of %d", cfg.JobsMaxEmails)})
+			return
+		}
+
+		job, err := m.Submit(emails)
+		if err != nil {
+			w.WriteHeader(http.StatusInternalServerError)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Failed to create job"})
+			return
+		}
+
+		w.Header().Set("Location", "/api/jobs/"+job.ID)
+		w.WriteHeader(http.StatusAccepted)
+		json.NewEncoder(w).Encode(job)
+	}
+}
+
+func JobStatusHandler(m *jobs.Manager) func(w http.ResponseWriter, r *http.Request, id string) {
+	return func(w http.ResponseWriter, r *http.Request, id string) {
+		w.Header().Set("Content-Type", "application/json")
+
+		job, err := m.Get(id)
+		if err != nil {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Job not found"})
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(job)
+	}
+}
+
+// JobResultsHandler streams the results saved so far as CSV (default) or
+// NDJSON (?format=ndjson). X-Job-Status tells whether the job has finished.
+func JobResultsHandler(m *jobs.Manager) func(w http.ResponseWriter, r *http.Request, id string) {
+	return func(w http.ResponseWriter, r *http.Request, id string) {
+		job, err := m.Get(id)
+		if err != nil {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "Job not found"})
+			return
+		}
+
+		format := strings.ToLower(r.URL.Query().Get("format"))
+		switch format {
+		case jobs.ResultsNDJSON:
+			w.Header().Set("Content-Type", "application/x-ndjson")
+		case "", jobs.ResultsCSV:
+			format = jobs.ResultsCSV
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		default:
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(ErrorResponse{Error: "format must be csv or ndjson"})
+			return
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, job.ID, format))
+		w.Header().Set("X-Job-Status", string(job.Status))
+
+		if err := m.Results(id, w, format); err != nil {
+			// Headers are already sent; the truncated body is all we can do
+			return
+		}
+	}
+}
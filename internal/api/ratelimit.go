@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 	"sync"
+	"time"
 
 	"golang.org/x/time/rate"
 )
@@ -53,6 +54,22 @@ func (rl *RateLimiter) RateLimit(next http.HandlerFunc) http.HandlerFunc {
 	}
 }
 
+// AllowN charges n tokens to the caller's limiter. When the request is
+// rejected it returns how long the caller should wait; ok is false with a
+// zero wait if n can never fit into the burst.
+func (rl *RateLimiter) AllowN(r *http.Request, n int) (retryAfter time.Duration, ok bool) {
+	limiter := rl.getLimiter(getClientIP(r))
+	res := limiter.ReserveN(time.Now(), n)
+	if !res.OK() {
+		return 0, false
+	}
+	if delay := res.Delay(); delay > 0 {
+		res.Cancel()
+		return delay, false
+	}
+	return 0, true
+}
+
 func getClientIP(r *http.Request) string {
 	// Check X-Forwarded-For header first
 	xff := r.Header.Get("X-Forwarded-For")
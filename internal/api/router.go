@@ -1,11 +1,18 @@
 package api
 
 import (
+	"context"
 	"embed"
+	"errors"
 	"io/fs"
+	"log"
 	"net/http"
+	"os"
+	"strings"
 
+	"workemailchecker/internal/admin"
 	"workemailchecker/internal/config"
+	"workemailchecker/internal/jobs"
 	"workemailchecker/internal/validator"
 
 	"github.com/gin-gonic/gin"
@@ -15,12 +22,124 @@ import (
 var staticFiles embed.FS
 
 func SetupRouter(cfg *config.Config) *gin.Engine {
-	go func() {
-		if err := validator.LoadFreeProviders(cfg.FreeProvidersURL); err != nil {
+	opts := validator.DefaultOptions()
+	opts.CorporateOverrides = cfg.CorporateOverrides
+	opts.PersonalOverrides = cfg.PersonalOverrides
+	for _, entry := range cfg.RoleAccounts {
+		if category, pattern, ok := strings.Cut(entry, ":"); ok {
+			opts.RoleAccounts[strings.TrimSpace(pattern)] = strings.TrimSpace(category)
+		}
+	}
+	if cfg.CompaniesFile != "" {
+		if companies, err := loadCompanies(cfg.CompaniesFile); err != nil {
+			log.Printf("Ignoring company graph: %v", err)
+		} else {
+			opts.Companies = append(opts.Companies, companies...)
 		}
+	}
+	if cfg.FingerprintsFile != "" {
+		if prints, err := loadFingerprints(cfg.FingerprintsFile); err != nil {
+			log.Printf("Ignoring fingerprints: %v", err)
+		} else {
+			opts.Fingerprints = append(opts.Fingerprints, prints...)
+		}
+	}
+	if cfg.RiskRulesFile != "" {
+		if weights, err := loadRiskWeights(cfg.RiskRulesFile); err != nil {
+			log.Printf("Ignoring risk rules: %v", err)
+		} else {
+			for code, w := range weights {
+				opts.RiskWeights[code] = w
+			}
+		}
+	}
+	if cfg.NormalizationFile != "" {
+		if rules, err := loadNormalizationRules(cfg.NormalizationFile); err != nil {
+			log.Printf("Ignoring normalization rules: %v", err)
+		} else {
+			opts.Normalization = append(opts.Normalization, rules...)
+		}
+	}
+	for _, entry := range cfg.ListFiles {
+		lists, err := loadListFiles(entry)
+		if err != nil {
+			log.Printf("Ignoring list %q: %v", entry, err)
+			continue
+		}
+		opts.Lists = append(opts.Lists, lists...)
+	}
+	resolver := validator.NewNetResolver(cfg.DNSServer)
+	var dnsCache *validator.CachingResolver
+	if cfg.DNSCacheSize > 0 {
+		// Query the nameserver directly so record TTLs are visible to the
+		// cache; the system resolver does not expose them
+		server := cfg.DNSServer
+		if server == "" {
+			server = validator.SystemNameserver()
+		}
+		if server != "" {
+			resolver = validator.NewDNSClient(server)
+		} else {
+			log.Printf("No nameserver in /etc/resolv.conf; caching DNS answers for %s instead of their TTLs", cfg.DNSCacheTTL)
+		}
+		cacheOpts := validator.DefaultCacheOptions()
+		cacheOpts.MaxEntries = cfg.DNSCacheSize
+		cacheOpts.DefaultTTL = cfg.DNSCacheTTL
+		cacheOpts.MaxTTL = cfg.DNSCacheMaxTTL
+		cacheOpts.NegativeTTL = cfg.DNSNegativeTTL
+		dnsCache = validator.NewCachingResolver(resolver, cacheOpts)
+		resolver = dnsCache
+	}
+	opts.Resolver = resolver
+	if cfg.SMTPCheckEnabled {
+		opts.SMTP = validator.NewSMTPProber(validator.SMTPOptions{
+			HeloName:      cfg.SMTPHeloName,
+			MailFrom:      cfg.SMTPMailFrom,
+			Port:          cfg.SMTPPort,
+			MaxConnsPerMX: cfg.SMTPMaxConnsPerMX,
+			CatchAllTTL:   cfg.SMTPCatchAllTTL,
+			ConnTimeout:   cfg.SMTPConnTimeout,
+		})
+	}
+	if cfg.MailAuthEnabled {
+		opts.MailAuth = &validator.MailAuthOptions{DKIMSelectors: cfg.DKIMSelectors}
+	}
+	opts.AllowPrivateMX = cfg.AllowPrivateMX
+	opts.DisposableMX = cfg.DisposableMX
+	opts.DisposableNS = cfg.DisposableNS
+	opts.Timeouts = validator.Timeouts{DNS: cfg.DNSTimeout, SMTP: cfg.SMTPTimeout}
+	v := validator.New(opts)
+	go func() {
+		n := v.LearnDisposableMX(context.Background())
+		log.Printf("Learned %d mail hosts of disposable domains", n)
 	}()
 
-	validator.SetOverrides(cfg.CorporateOverrides, cfg.PersonalOverrides)
+	freeList := validator.NewRemoteList(v, validator.RemoteListOptions{
+		Kind:         validator.ListFree,
+		URL:          cfg.FreeProvidersURL,
+		Interval:     cfg.FreeListRefresh,
+		SnapshotPath: cfg.FreeListSnapshot,
+		Fallback:     validator.FreeProvidersFallback(),
+	})
+	if err := freeList.Bootstrap(); err != nil {
+		log.Printf("Free providers fallback unavailable: %v", err)
+	}
+	go freeList.Run(context.Background())
+
+	jobManager, err := jobs.NewManager(cfg.JobsDir, v, cfg.JobsWorkers)
+	if err != nil {
+		log.Printf("Bulk jobs disabled: %v", err)
+	} else {
+		go jobManager.Run(context.Background())
+	}
+
+	adminAuth := NewAdminAuth(cfg.AdminTokens)
+	var adminStore *admin.Store
+	if adminAuth.Enabled() {
+		if adminStore, err = admin.NewStore(cfg.AdminDir, v); err != nil {
+			log.Printf("Admin API disabled: %v", err)
+		}
+	}
 
 	router := gin.New()
 
@@ -32,8 +151,22 @@ func SetupRouter(cfg *config.Config) *gin.Engine {
 
 	api := router.Group("/api")
 	{
-		api.POST("/check", toGin(rateLimiter.RateLimit(EmailCheckHandler(cfg, aiLimiter))))
-		api.GET("/health", toGin(HealthCheckHandler))
+		api.POST("/check", toGin(rateLimiter.RateLimit(EmailCheckHandler(cfg, v, aiLimiter))))
+		api.POST("/check/batch", toGin(BatchCheckHandler(cfg, v, rateLimiter)))
+		if jobManager != nil {
+			api.POST("/jobs", toGin(rateLimiter.RateLimit(CreateJobHandler(cfg, jobManager))))
+			api.GET("/jobs/:id", withID(JobStatusHandler(jobManager)))
+			api.GET("/jobs/:id/results", withID(JobResultsHandler(jobManager)))
+		}
+		if adminStore != nil {
+			domains := toGin(adminAuth.Require(AdminDomainsHandler(v, adminStore)))
+			api.GET("/admin/domains", domains)
+			api.POST("/admin/domains", domains)
+			api.DELETE("/admin/domains", domains)
+			api.POST("/admin/domains/import", toGin(adminAuth.Require(AdminImportHandler(adminStore, cfg.JobsMaxUploadBytes))))
+			api.GET("/admin/audit", toGin(adminAuth.Require(AdminAuditHandler(adminStore))))
+		}
+		api.GET("/health", toGin(HealthCheckHandler(dnsCache, freeList)))
 	}
 
 	staticFS, err := fs.Sub(staticFiles, "static")
@@ -83,3 +216,58 @@ func toGin(h http.HandlerFunc) gin.HandlerFunc {
 		h(c.Writer, c.Request)
 	}
 }
+
+func withID(h func(http.ResponseWriter, *http.Request, string)) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h(c.Writer, c.Request, c.Param("id"))
+	}
+}
+
+func loadCompanies(path string) ([]validator.Company, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return validator.LoadCompanies(f)
+}
+
+func loadFingerprints(path string) ([]validator.Fingerprint, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return validator.LoadFingerprints(f)
+}
+
+func loadRiskWeights(path string) (map[string]int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return validator.LoadRiskWeights(f)
+}
+
+func loadNormalizationRules(path string) ([]validator.NormalizationRule, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return validator.LoadNormalizationRules(f)
+}
+
+// loadListFiles reads a "list:path" entry from LIST_FILES.
+func loadListFiles(entry string) ([]validator.DomainList, error) {
+	name, path, ok := strings.Cut(entry, ":")
+	if !ok {
+		return nil, errors.New("expected list:path")
+	}
+	kind, err := validator.ParseListKind(name)
+	if err != nil {
+		return nil, err
+	}
+	return validator.ReadListPath(kind, strings.TrimSpace(path))
+}
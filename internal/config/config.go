@@ -1,9 +1,11 @@
 package config
 
 import (
+	"log"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type Config struct {
@@ -11,6 +13,8 @@ type Config struct {
 	RateLimitRPS       int
 	RateLimitBurst     int
 	FreeProvidersURL   string
+	FreeListRefresh    time.Duration
+	FreeListSnapshot   string
 	EnableAICheck      bool
 	PerplexityAPIKey   string
 	PerplexityAPIURL   string
@@ -19,6 +23,41 @@ type Config struct {
 	PersonalOverrides  []string
 	AIRateLimitRPS     float64
 	AIRateLimitBurst   int
+	DNSServer          string
+	DNSTimeout         time.Duration
+	SMTPTimeout        time.Duration
+	AITimeout          time.Duration
+	DNSCacheSize       int
+	DNSCacheTTL        time.Duration
+	DNSCacheMaxTTL     time.Duration
+	DNSNegativeTTL     time.Duration
+	BatchMaxSize       int
+	BatchWorkers       int
+	BatchRateLimitMode string // "item" or "batch"
+	JobsDir            string
+	JobsWorkers        int
+	JobsMaxEmails      int
+	JobsMaxUploadBytes int64
+	SMTPCheckEnabled   bool
+	SMTPHeloName       string
+	SMTPMailFrom       string
+	SMTPPort           string
+	SMTPMaxConnsPerMX  int
+	SMTPCatchAllTTL    time.Duration
+	SMTPConnTimeout    time.Duration
+	RoleAccounts       []string // extra "category:pattern" entries
+	CompaniesFile      string   // JSON company graph added to the built-in one
+	FingerprintsFile   string   // JSON mail platform fingerprints added to the built-in ones
+	RiskRulesFile      string   // JSON reason code -> weight overrides
+	NormalizationFile  string   // JSON canonical_email rules added to the built-in ones
+	ListFiles          []string // "list:path" entries, path may be a directory
+	AdminTokens        []string // "actor:token" entries, none disables the admin API
+	AdminDir           string
+	MailAuthEnabled    bool
+	DKIMSelectors      []string // overrides the built-in selector list
+	AllowPrivateMX     bool
+	DisposableMX       []string // mail host patterns of disposable services
+	DisposableNS       []string // nameserver patterns of disposable services
 }
 
 func Load() *Config {
@@ -28,6 +67,8 @@ func Load() *Config {
 		RateLimitRPS:       getEnvAsInt("RATE_LIMIT_RPS", 5),
 		RateLimitBurst:     getEnvAsInt("RATE_LIMIT_BURST", 10),
 		FreeProvidersURL:   getEnv("FREE_PROVIDERS_URL", "https://raw.githubusercontent.com/Kikobeats/free-email-domains/master/domains.json"),
+		FreeListRefresh:    getEnvAsDuration("FREE_PROVIDERS_REFRESH", 24*time.Hour),
+		FreeListSnapshot:   getEnv("FREE_PROVIDERS_SNAPSHOT", "data/lists/free_providers.json"),
 		EnableAICheck:      getEnvAsBool("ENABLE_AI_CHECK", false),
 		PerplexityAPIKey:   getEnv("PERPLEXITY_API_KEY", ""),
 		PerplexityAPIURL:   getEnv("PERPLEXITY_API_URL", "https://api.perplexity.ai/chat/completions"),
@@ -36,6 +77,41 @@ func Load() *Config {
 		PersonalOverrides:  getEnvAsCSV("PERSONAL_OVERRIDES", ","),
 		AIRateLimitRPS:     getEnvAsFloat("AI_RATE_LIMIT_RPS", 0.5),
 		AIRateLimitBurst:   getEnvAsInt("AI_RATE_LIMIT_BURST", 1),
+		DNSServer:          getEnv("DNS_SERVER", ""),
+		DNSTimeout:         getEnvAsDuration("DNS_TIMEOUT", 5*time.Second),
+		SMTPTimeout:        getEnvAsDuration("SMTP_TIMEOUT", 10*time.Second),
+		AITimeout:          getEnvAsDuration("AI_TIMEOUT", 12*time.Second),
+		DNSCacheSize:       getEnvAsInt("DNS_CACHE_SIZE", 10000),
+		DNSCacheTTL:        getEnvAsDuration("DNS_CACHE_TTL", 5*time.Minute),
+		DNSCacheMaxTTL:     getEnvAsDuration("DNS_CACHE_MAX_TTL", time.Hour),
+		DNSNegativeTTL:     getEnvAsDuration("DNS_NEGATIVE_TTL", time.Minute),
+		BatchMaxSize:       getEnvAsInt("BATCH_MAX_SIZE", 100),
+		BatchWorkers:       getEnvAsInt("BATCH_WORKERS", 8),
+		BatchRateLimitMode: getEnvAsBatchMode("BATCH_RATE_LIMIT_MODE", "batch"),
+		JobsDir:            getEnv("JOBS_DIR", "data/jobs"),
+		JobsWorkers:        getEnvAsInt("JOBS_WORKERS", 8),
+		JobsMaxEmails:      getEnvAsInt("JOBS_MAX_EMAILS", 1000000),
+		JobsMaxUploadBytes: int64(getEnvAsInt("JOBS_MAX_UPLOAD_BYTES", 64<<20)),
+		SMTPCheckEnabled:   getEnvAsBool("SMTP_CHECK_ENABLED", false),
+		SMTPHeloName:       getEnv("SMTP_HELO_NAME", "localhost"),
+		SMTPMailFrom:       getEnv("SMTP_MAIL_FROM", ""),
+		SMTPPort:           getEnv("SMTP_PORT", "25"),
+		SMTPMaxConnsPerMX:  getEnvAsInt("SMTP_MAX_CONNS_PER_MX", 2),
+		SMTPCatchAllTTL:    getEnvAsDuration("SMTP_CATCH_ALL_TTL", 24*time.Hour),
+		SMTPConnTimeout:    getEnvAsDuration("SMTP_CONN_TIMEOUT", 30*time.Second),
+		RoleAccounts:       getEnvAsCSV("ROLE_ACCOUNTS", ","),
+		CompaniesFile:      getEnv("COMPANIES_FILE", ""),
+		FingerprintsFile:   getEnv("FINGERPRINTS_FILE", ""),
+		RiskRulesFile:      getEnv("RISK_RULES_FILE", ""),
+		NormalizationFile:  getEnv("NORMALIZATION_RULES_FILE", ""),
+		ListFiles:          getEnvAsCSV("LIST_FILES", ","),
+		AdminTokens:        getEnvAsCSV("ADMIN_TOKENS", ","),
+		AdminDir:           getEnv("ADMIN_DIR", "data/admin"),
+		MailAuthEnabled:    getEnvAsBool("MAIL_AUTH_ENABLED", false),
+		DKIMSelectors:      getEnvAsCSV("DKIM_SELECTORS", ","),
+		AllowPrivateMX:     getEnvAsBool("ALLOW_PRIVATE_MX", false),
+		DisposableMX:       getEnvAsCSV("DISPOSABLE_MX", ","),
+		DisposableNS:       getEnvAsCSV("DISPOSABLE_NS", ","),
 	}
 }
 
@@ -67,6 +143,18 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 	return defaultValue
 }
 
+// getEnvAsBatchMode accepts "batch" or "item"; anything else is logged and
+// replaced by defaultValue rather than silently charging per batch.
+func getEnvAsBatchMode(key, defaultValue string) string {
+	switch mode := strings.ToLower(getEnv(key, defaultValue)); mode {
+	case "batch", "item":
+		return mode
+	default:
+		log.Printf("Ignoring %s=%q: must be batch or item, using %s", key, mode, defaultValue)
+		return defaultValue
+	}
+}
+
 func getEnvAsCSV(key, sep string) []string {
 	if value := os.Getenv(key); value != "" {
 		parts := []string{}
@@ -90,6 +178,15 @@ func getEnvAsFloat(key string, defaultValue float64) float64 {
 	return defaultValue
 }
 
+func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
+	if value := os.Getenv(key); value != "" {
+		if d, err := time.ParseDuration(value); err == nil {
+			return d
+		}
+	}
+	return defaultValue
+}
+
 func loadDotEnv() {
 	b, err := os.ReadFile(".env")
 	if err != nil {
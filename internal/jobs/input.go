@@ -0,0 +1,106 @@
+package jobs
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Input formats accepted by ParseList.
+const (
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+	FormatText = "text"
+)
+
+// FormatFromContentType maps a request Content-Type to an input format,
+// defaulting to newline-delimited text.
+func FormatFromContentType(ct string) string {
+	ct = strings.ToLower(ct)
+	switch {
+	case strings.Contains(ct, "json"):
+		return FormatJSON
+	case strings.Contains(ct, "csv"):
+		return FormatCSV
+	default:
+		return FormatText
+	}
+}
+
+// ParseList reads an email list. JSON input may be an array of strings or
+// an object with an "emails" array; CSV input uses the "email" column when a
+// header names one and the first column otherwise. Blank entries are dropped.
+func ParseList(r io.Reader, format string) ([]string, error) {
+	var emails []string
+	switch format {
+	case FormatJSON:
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(raw, &emails); err != nil {
+			var obj struct {
+				Emails []string `json:"emails"`
+			}
+			if err := json.Unmarshal(raw, &obj); err != nil {
+				return nil, errors.New("expected a JSON array of emails or an object with an \"emails\" array")
+			}
+			emails = obj.Emails
+		}
+	case FormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		column := 0
+		first := true
+		for {
+			rec, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid CSV: %w", err)
+			}
+			if first {
+				first = false
+				if idx := headerIndex(rec, "email"); idx >= 0 {
+					column = idx
+					continue
+				}
+			}
+			if column < len(rec) {
+				emails = append(emails, rec[column])
+			}
+		}
+	case FormatText:
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			emails = append(emails, sc.Text())
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+
+	out := emails[:0]
+	for _, e := range emails {
+		if e = strings.TrimSpace(e); e != "" {
+			out = append(out, e)
+		}
+	}
+	return out, nil
+}
+
+func headerIndex(rec []string, name string) int {
+	for i, col := range rec {
+		if strings.EqualFold(strings.TrimSpace(col), name) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,432 @@
+package jobs
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"workemailchecker/internal/validator"
+)
+
+type Status string
+
+const (
+	StatusQueued    Status = "queued"
+	StatusRunning   Status = "running"
+	StatusCompleted Status = "completed"
+	StatusFailed    Status = "failed"
+)
+
+// Output formats for Results.
+const (
+	ResultsCSV    = "csv"
+	ResultsNDJSON = "ndjson"
+)
+
+// chunkSize is how many addresses are validated between progress saves.
+const chunkSize = 200
+
+var ErrNotFound = errors.New("job not found")
+
+type Job struct {
+	ID          string         `json:"id"`
+	Status      Status         `json:"status"`
+	Total       int            `json:"total"`
+	Processed   int            `json:"processed"`
+	Valid       int            `json:"valid"`
+	Counts      map[string]int `json:"counts"` // per provider_type
+	Error       string         `json:"error,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+}
+
+// record is the on-disk state of a job. Offset is the size of the results
+// file after the last saved chunk; anything past it is discarded on resume.
+type record struct {
+	Job    Job   `json:"job"`
+	Offset int64 `json:"offset"`
+}
+
+// Manager runs bulk verification jobs in the background. Every job is kept
+// in dir as <id>.json (state), <id>.input (one JSON string per line) and
+// <id>.ndjson (results), so unfinished jobs resume after a restart.
+type Manager struct {
+	dir     string
+	v       *validator.Validator
+	workers int
+
+	mu      sync.Mutex
+	jobs    map[string]*record
+	pending []string      // queued job IDs, FIFO
+	wake    chan struct{} // signals Run that pending is non-empty
+}
+
+func NewManager(dir string, v *validator.Validator, workers int) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create jobs dir: %w", err)
+	}
+	m := &Manager{
+		dir:     dir,
+		v:       v,
+		workers: workers,
+		jobs:    make(map[string]*record),
+		wake:    make(chan struct{}, 1),
+	}
+	if err := m.load(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// load restores job state from disk and requeues unfinished jobs in
+// creation order.
+func (m *Manager) load() error {
+	paths, err := filepath.Glob(filepath.Join(m.dir, "*.json"))
+	if err != nil {
+		return err
+	}
+	var pending []*record
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return fmt.Errorf("failed to read job state: %w", err)
+		}
+		var rec record
+		if err := json.Unmarshal(b, &rec); err != nil {
+			log.Printf("jobs: skipping corrupt state file %s: %v", p, err)
+			continue
+		}
+		m.jobs[rec.Job.ID] = &rec
+		if rec.Job.Status == StatusQueued || rec.Job.Status == StatusRunning {
+			pending = append(pending, &rec)
+		}
+	}
+	sort.Slice(pending, func(i, j int) bool {
+		return pending[i].Job.CreatedAt.Before(pending[j].Job.CreatedAt)
+	})
+	for _, rec := range pending {
+		m.enqueue(rec.Job.ID)
+	}
+	return nil
+}
+
+// Run processes queued jobs until ctx is done.
+func (m *Manager) Run(ctx context.Context) {
+	for {
+		id, ok := m.next()
+		if !ok {
+			select {
+			case <-ctx.Done():
+				return
+			case <-m.wake:
+			}
+			continue
+		}
+		if err := m.process(ctx, id); err != nil && ctx.Err() == nil {
+			log.Printf("jobs: job %s failed: %v", id, err)
+			m.update(id, func(rec *record) {
+				rec.Job.Status = StatusFailed
+				rec.Job.Error = err.Error()
+			})
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// Submit stores a new job and queues it for processing.
+func (m *Manager) Submit(emails []string) (*Job, error) {
+	if len(emails) == 0 {
+		return nil, errors.New("empty email list")
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+
+	// Addresses are stored JSON-encoded so that one containing a newline
+	// cannot shift the lines that follow it
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, e := range emails {
+		if err := enc.Encode(e); err != nil {
+			return nil, err
+		}
+	}
+	if err := os.WriteFile(m.path(id, ".input"), buf.Bytes(), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to store job input: %w", err)
+	}
+
+	now := time.Now().UTC()
+	rec := &record{Job: Job{
+		ID:        id,
+		Status:    StatusQueued,
+		Total:     len(emails),
+		Counts:    make(map[string]int),
+		CreatedAt: now,
+		UpdatedAt: now,
+	}}
+	m.mu.Lock()
+	m.jobs[id] = rec
+	err = m.save(rec)
+	job := copyJob(&rec.Job)
+	m.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	m.enqueue(id)
+	return job, nil
+}
+
+func (m *Manager) Get(id string) (*Job, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.jobs[id]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return copyJob(&rec.Job), nil
+}
+
+// Results writes the results saved so far in the given format.
+func (m *Manager) Results(id string, w io.Writer, format string) error {
+	m.mu.Lock()
+	rec, ok := m.jobs[id]
+	var offset int64
+	if ok {
+		offset = rec.Offset
+	}
+	m.mu.Unlock()
+	if !ok {
+		return ErrNotFound
+	}
+
+	var r io.Reader = bytes.NewReader(nil)
+	f, err := os.Open(m.path(id, ".ndjson"))
+	switch {
+	case err == nil:
+		defer f.Close()
+		r = io.LimitReader(f, offset)
+	case !errors.Is(err, os.ErrNotExist):
+		return err
+	}
+
+	if format == ResultsNDJSON {
+		_, err := io.Copy(w, r)
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"email", "canonical_email", "valid", "risk_score", "reasons", "provider_type", "provider_name", "classified_by", "is_disposable", "is_corporate", "is_personal", "message"})
+	sc := bufio.NewScanner(r)
+	sc.Buffer(make([]byte, 64*1024), 1024*1024)
+	for sc.Scan() {
+		var res validator.ValidationResult
+		if err := json.Unmarshal(sc.Bytes(), &res); err != nil {
+			return err
+		}
+		cw.Write([]string{
+			res.Email,
+			res.CanonicalEmail,
+			strconv.FormatBool(res.Valid),
+			strconv.Itoa(res.RiskScore),
+			strings.Join(res.Reasons, ";"),
+			res.ProviderType,
+			res.ProviderName,
+			res.ClassifiedBy,
+			strconv.FormatBool(res.IsDisposable),
+			strconv.FormatBool(res.IsCorporate),
+			strconv.FormatBool(res.IsPersonal),
+			res.Message,
+		})
+	}
+	if err := sc.Err(); err != nil {
+		return err
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func (m *Manager) process(ctx context.Context, id string) error {
+	m.mu.Lock()
+	rec, ok := m.jobs[id]
+	if !ok || (rec.Job.Status != StatusQueued && rec.Job.Status != StatusRunning) {
+		m.mu.Unlock()
+		return nil
+	}
+	start, offset := rec.Job.Processed, rec.Offset
+	m.mu.Unlock()
+
+	input, err := os.ReadFile(m.path(id, ".input"))
+	if err != nil {
+		return fmt.Errorf("failed to read job input: %w", err)
+	}
+	emails, err := decodeInput(input)
+	if err != nil {
+		return fmt.Errorf("failed to read job input: %w", err)
+	}
+
+	out, err := os.OpenFile(m.path(id, ".ndjson"), os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	// Drop results written after the last saved checkpoint
+	if err := out.Truncate(offset); err != nil {
+		return err
+	}
+	if _, err := out.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	m.update(id, func(rec *record) { rec.Job.Status = StatusRunning })
+
+	for i := start; i < len(emails); i += chunkSize {
+		end := i + chunkSize
+		if end > len(emails) {
+			end = len(emails)
+		}
+		results := m.v.ValidateBatch(ctx, emails[i:end], m.workers, validator.CheckOptions{})
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+
+		var buf bytes.Buffer
+		enc := json.NewEncoder(&buf)
+		for _, res := range results {
+			if err := enc.Encode(res); err != nil {
+				return err
+			}
+		}
+		n, err := out.Write(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf("failed to write results: %w", err)
+		}
+
+		m.update(id, func(rec *record) {
+			rec.Offset += int64(n)
+			rec.Job.Processed = end
+			for _, res := range results {
+				rec.Job.Counts[res.ProviderType]++
+				if res.Valid {
+					rec.Job.Valid++
+				}
+			}
+		})
+	}
+
+	m.update(id, func(rec *record) {
+		now := time.Now().UTC()
+		rec.Job.Status = StatusCompleted
+		rec.Job.CompletedAt = &now
+	})
+	return nil
+}
+
+// update applies fn to the job and persists it. Persistence errors are
+// logged; the in-memory state stays authoritative until the next save.
+func (m *Manager) update(id string, fn func(rec *record)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	rec, ok := m.jobs[id]
+	if !ok {
+		return
+	}
+	fn(rec)
+	rec.Job.UpdatedAt = time.Now().UTC()
+	if err := m.save(rec); err != nil {
+		log.Printf("jobs: %v", err)
+	}
+}
+
+// save writes the job state atomically; m.mu must be held.
+func (m *Manager) save(rec *record) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	tmp := m.path(rec.Job.ID, ".json.tmp")
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("failed to save job %s: %w", rec.Job.ID, err)
+	}
+	if err := os.Rename(tmp, m.path(rec.Job.ID, ".json")); err != nil {
+		return fmt.Errorf("failed to save job %s: %w", rec.Job.ID, err)
+	}
+	return nil
+}
+
+func (m *Manager) enqueue(id string) {
+	m.mu.Lock()
+	m.pending = append(m.pending, id)
+	m.mu.Unlock()
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *Manager) next() (string, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.pending) == 0 {
+		return "", false
+	}
+	id := m.pending[0]
+	m.pending = m.pending[1:]
+	return id, true
+}
+
+func (m *Manager) path(id, ext string) string {
+	return filepath.Join(m.dir, id+ext)
+}
+
+func newID() (string, error) {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func copyJob(j *Job) *Job {
+	c := *j
+	c.Counts = make(map[string]int, len(j.Counts))
+	for k, v := range j.Counts {
+		c.Counts[k] = v
+	}
+	return &c
+}
+
+// decodeInput reads the addresses stored by Submit.
+func decodeInput(b []byte) ([]string, error) {
+	var emails []string
+	for _, line := range bytes.Split(b, []byte{'\n'}) {
+		if len(line) == 0 {
+			continue
+		}
+		var e string
+		if err := json.Unmarshal(line, &e); err != nil {
+			return nil, err
+		}
+		emails = append(emails, e)
+	}
+	return emails, nil
+}
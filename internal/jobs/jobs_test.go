@@ -0,0 +1,151 @@
+package jobs
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"workemailchecker/internal/validator"
+)
+
+func TestParseList(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+		want   []string
+	}{
+		{FormatJSON, `["a@x.com", " b@y.com ", ""]`, []string{"a@x.com", "b@y.com"}},
+		{FormatJSON, `{"emails": ["a@x.com"]}`, []string{"a@x.com"}},
+		{FormatCSV, "name,Email\nA,a@x.com\nB,b@y.com\n", []string{"a@x.com", "b@y.com"}},
+		{FormatCSV, "a@x.com,A\nb@y.com,B\n", []string{"a@x.com", "b@y.com"}},
+		{FormatText, "a@x.com\r\n\nb@y.com\n", []string{"a@x.com", "b@y.com"}},
+	}
+	for _, tt := range tests {
+		got, err := ParseList(strings.NewReader(tt.input), tt.format)
+		if err != nil {
+			t.Errorf("ParseList(%s, %q): %v", tt.format, tt.input, err)
+			continue
+		}
+		if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+			t.Errorf("ParseList(%s, %q) = %v, want %v", tt.format, tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestManagerRunAndReload(t *testing.T) {
+	dir := t.TempDir()
+	opts := validator.DefaultOptions()
+	opts.Resolver = validator.NewFakeResolver().AddMX("acme.example", "mx.acme.example.", 10).AddHost("mx.acme.example", "64.233.184.26")
+	v := validator.New(opts)
+
+	m, err := NewManager(dir, v, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job, err := m.Submit([]string{"a@acme.example", "b@yopmail.com", "c@acme.example"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m.Run(ctx)
+
+	if j := waitCompleted(t, m, job.ID); j.Processed != 3 || j.Counts["corporate"] != 2 || j.Counts["disposable"] != 1 {
+		t.Fatalf("job = %+v", j)
+	}
+
+	// A fresh manager on the same directory sees the finished job
+	m2, err := NewManager(dir, v, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	if err := m2.Results(job.ID, &buf, ResultsCSV); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 4 || !strings.HasPrefix(lines[1], "a@acme.example,a@acme.example,true,0,,corporate") {
+		t.Errorf("results CSV = %q", buf.String())
+	}
+}
+
+func TestManagerResume(t *testing.T) {
+	dir := t.TempDir()
+	opts := validator.DefaultOptions()
+	opts.Resolver = validator.NewFakeResolver().AddMX("acme.example", "mx.acme.example.", 10).AddHost("mx.acme.example", "64.233.184.26")
+	v := validator.New(opts)
+
+	m, err := NewManager(dir, v, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	emails := []string{"a@acme.example", "b\n@acme.example", "c@acme.example", "d@yopmail.com"}
+	job, err := m.Submit(emails)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a process killed mid-chunk: one result checkpointed, a
+	// second one half written past the checkpoint
+	first, _ := json.Marshal(v.ValidateBatch(context.Background(), emails[:1], 1, validator.CheckOptions{})[0])
+	first = append(first, '\n')
+	partial := append(append([]byte{}, first...), `{"email":"b`...)
+	if err := os.WriteFile(filepath.Join(dir, job.ID+".ndjson"), partial, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m.update(job.ID, func(rec *record) {
+		rec.Job.Status = StatusRunning
+		rec.Job.Processed = 1
+		rec.Job.Counts["corporate"] = 1
+		rec.Job.Valid = 1
+		rec.Offset = int64(len(first))
+	})
+
+	m2, err := NewManager(dir, v, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go m2.Run(ctx)
+
+	j := waitCompleted(t, m2, job.ID)
+	if j.Total != 4 || j.Processed != 4 || j.Counts["corporate"] != 2 || j.Counts["disposable"] != 1 {
+		t.Errorf("job = %+v", j)
+	}
+	var buf bytes.Buffer
+	if err := m2.Results(job.ID, &buf, ResultsNDJSON); err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(emails) {
+		t.Fatalf("%d result rows, want %d:\n%s", len(lines), len(emails), buf.String())
+	}
+	for i, line := range lines {
+		var res validator.ValidationResult
+		if err := json.Unmarshal([]byte(line), &res); err != nil || res.Email != emails[i] {
+			t.Errorf("row %d = %s, want %q", i, line, emails[i])
+		}
+	}
+}
+
+func waitCompleted(t *testing.T, m *Manager, id string) *Job {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		j, _ := m.Get(id)
+		if j.Status == StatusCompleted {
+			return j
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job did not complete: %+v", j)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
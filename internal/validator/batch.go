@@ -0,0 +1,63 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+// ValidateBatch validates emails concurrently with at most workers
+// goroutines and returns the results in input order. DNS answers are shared
+// across the batch, so each distinct domain is resolved once.
+func (v *Validator) ValidateBatch(ctx context.Context, emails []string, workers int, opts CheckOptions) []*ValidationResult {
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(emails) {
+		workers = len(emails)
+	}
+	resolver := NewCachingResolver(v.resolver, CacheOptions{DefaultTTL: time.Hour, NegativeTTL: time.Hour, FetchTimeout: DefaultCacheOptions().FetchTimeout})
+
+	results := make([]*ValidationResult, len(emails))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range jobs {
+				results[idx] = v.validate(ctx, emails[idx], resolver, opts)
+			}
+		}()
+	}
+	for i := range emails {
+		if ctx.Err() == nil {
+			select {
+			case jobs <- i:
+				continue
+			case <-ctx.Done():
+			}
+		}
+		// Nobody waits for the rest; report them without starting work
+		for ; i < len(emails); i++ {
+			results[i] = v.unchecked(emails[i], ctx.Err())
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// unchecked is the result of an address a cancelled batch never started.
+// A batch that ran out of time reports the DNS stage as timed out.
+func (v *Validator) unchecked(email string, err error) *ValidationResult {
+	r := &ValidationResult{Email: email, ProviderType: "unknown", Message: "Not checked: batch cancelled"}
+	if errors.Is(err, context.DeadlineExceeded) {
+		r.TimedOut = []string{StageDNS}
+		r.Message = "Not checked: batch timed out"
+	}
+	v.Score(r)
+	return r
+}
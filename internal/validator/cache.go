@@ -0,0 +1,224 @@
+package validator
+
+import (
+	"container/list"
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// CacheOptions configures CachingResolver.
+type CacheOptions struct {
+	MaxEntries   int           // LRU size cap
+	DefaultTTL   time.Duration // used when the upstream resolver does not report TTLs
+	MinTTL       time.Duration
+	MaxTTL       time.Duration
+	NegativeTTL  time.Duration // how long NXDOMAIN / no-data answers are kept
+	FetchTimeout time.Duration // bounds a shared upstream query, which runs detached from its callers
+}
+
+func DefaultCacheOptions() CacheOptions {
+	return CacheOptions{
+		MaxEntries:   10000,
+		DefaultTTL:   5 * time.Minute,
+		MinTTL:       30 * time.Second,
+		MaxTTL:       time.Hour,
+		NegativeTTL:  time.Minute,
+		FetchTimeout: 10 * time.Second,
+	}
+}
+
+type CacheStats struct {
+	Hits    uint64 `json:"hits"`
+	Misses  uint64 `json:"misses"`
+	Entries int    `json:"entries"`
+}
+
+// CachingResolver memoises MX, A/AAAA, TXT and NS answers of another
+// resolver. Positive answers live for their record TTL (clamped to
+// MinTTL..MaxTTL, or DefaultTTL when the TTL is unknown; a TTL of 0 is not
+// cached), not-found answers for NegativeTTL, and concurrent lookups of the
+// same name share one upstream query, which outlives a caller that gives
+// up. Timeouts and other transient errors are never cached.
+type CachingResolver struct {
+	next Resolver
+	opts CacheOptions
+
+	mu       sync.Mutex
+	lru      *list.List
+	entries  map[cacheKey]*list.Element
+	inflight map[cacheKey]*cacheCall
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type cacheKey struct {
+	qtype string
+	name  string
+}
+
+type cacheEntry struct {
+	key     cacheKey
+	value   any
+	err     error
+	expires time.Time
+}
+
+type cacheCall struct {
+	done  chan struct{}
+	value any
+	err   error
+}
+
+func NewCachingResolver(next Resolver, opts CacheOptions) *CachingResolver {
+	return &CachingResolver{
+		next:     next,
+		opts:     opts,
+		lru:      list.New(),
+		entries:  make(map[cacheKey]*list.Element),
+		inflight: make(map[cacheKey]*cacheCall),
+	}
+}
+
+func (c *CachingResolver) Stats() CacheStats {
+	c.mu.Lock()
+	entries := c.lru.Len()
+	c.mu.Unlock()
+	return CacheStats{Hits: c.hits.Load(), Misses: c.misses.Load(), Entries: entries}
+}
+
+func (c *CachingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	v, err := c.lookup(ctx, "MX", name, func(ctx context.Context) (any, time.Duration, error) {
+		if tr, ok := c.next.(TTLResolver); ok {
+			return tr.LookupMXTTL(ctx, name)
+		}
+		mx, err := c.next.LookupMX(ctx, name)
+		return mx, UnknownTTL, err
+	})
+	mx, _ := v.([]*net.MX)
+	return mx, err
+}
+
+func (c *CachingResolver) LookupHost(ctx context.Context, name string) ([]string, error) {
+	v, err := c.lookup(ctx, "A", name, func(ctx context.Context) (any, time.Duration, error) {
+		if tr, ok := c.next.(TTLResolver); ok {
+			return tr.LookupHostTTL(ctx, name)
+		}
+		hosts, err := c.next.LookupHost(ctx, name)
+		return hosts, UnknownTTL, err
+	})
+	hosts, _ := v.([]string)
+	return hosts, err
+}
+
+func (c *CachingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	v, err := c.lookup(ctx, "TXT", name, func(ctx context.Context) (any, time.Duration, error) {
+		if tr, ok := c.next.(TTLResolver); ok {
+			return tr.LookupTXTTTL(ctx, name)
+		}
+		txt, err := c.next.LookupTXT(ctx, name)
+		return txt, UnknownTTL, err
+	})
+	txt, _ := v.([]string)
+	return txt, err
+}
+
+func (c *CachingResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	v, err := c.lookup(ctx, "NS", name, func(ctx context.Context) (any, time.Duration, error) {
+		ns, err := lookupNS(ctx, c.next, name)
+		return ns, UnknownTTL, err
+	})
+	ns, _ := v.([]*net.NS)
+	return ns, err
+}
+
+func (c *CachingResolver) lookup(ctx context.Context, qtype, name string, fetch func(context.Context) (any, time.Duration, error)) (any, error) {
+	key := cacheKey{qtype: qtype, name: dnsKey(name)}
+	now := time.Now()
+
+	c.mu.Lock()
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*cacheEntry)
+		if now.Before(e.expires) {
+			c.lru.MoveToFront(el)
+			c.mu.Unlock()
+			c.hits.Add(1)
+			return e.value, e.err
+		}
+		c.lru.Remove(el)
+		delete(c.entries, key)
+	}
+	c.misses.Add(1)
+	call, ok := c.inflight[key]
+	if !ok {
+		call = &cacheCall{done: make(chan struct{})}
+		c.inflight[key] = call
+		go c.fetch(context.WithoutCancel(ctx), key, call, fetch)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-call.done:
+		return call.value, call.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// fetch runs the upstream query for key and publishes its result to every
+// caller waiting on call.
+func (c *CachingResolver) fetch(ctx context.Context, key cacheKey, call *cacheCall, fetch func(context.Context) (any, time.Duration, error)) {
+	if c.opts.FetchTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.opts.FetchTimeout)
+		defer cancel()
+	}
+	value, ttl, err := fetch(ctx)
+	call.value, call.err = value, err
+
+	c.mu.Lock()
+	delete(c.inflight, key)
+	if expiry, ok := c.expiry(ttl, err); ok {
+		c.store(&cacheEntry{key: key, value: value, err: err, expires: time.Now().Add(expiry)})
+	}
+	c.mu.Unlock()
+	close(call.done)
+}
+
+// expiry decides whether and for how long an answer may be cached.
+func (c *CachingResolver) expiry(ttl time.Duration, err error) (time.Duration, bool) {
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound && c.opts.NegativeTTL > 0 {
+			return c.opts.NegativeTTL, true
+		}
+		return 0, false
+	}
+	switch {
+	case ttl < 0:
+		ttl = c.opts.DefaultTTL
+	case ttl == 0:
+		// Published as "do not cache"
+		return 0, false
+	case ttl < c.opts.MinTTL:
+		ttl = c.opts.MinTTL
+	}
+	if c.opts.MaxTTL > 0 && ttl > c.opts.MaxTTL {
+		ttl = c.opts.MaxTTL
+	}
+	return ttl, ttl > 0
+}
+
+// store inserts e and evicts least recently used entries; c.mu must be held.
+func (c *CachingResolver) store(e *cacheEntry) {
+	c.entries[e.key] = c.lru.PushFront(e)
+	for c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+		oldest := c.lru.Back()
+		c.lru.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
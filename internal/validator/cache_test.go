@@ -0,0 +1,162 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type countingResolver struct {
+	*FakeResolver
+	mxCalls atomic.Int32
+}
+
+func (c *countingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	c.mxCalls.Add(1)
+	return c.FakeResolver.LookupMX(ctx, name)
+}
+
+func TestCachingResolver(t *testing.T) {
+	upstream := &countingResolver{FakeResolver: NewFakeResolver().AddMX("a.example", "mx.a.example.", 10)}
+	opts := DefaultCacheOptions()
+	opts.MaxEntries = 2
+	cache := NewCachingResolver(upstream, opts)
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if mx, err := cache.LookupMX(ctx, "a.example"); err != nil || len(mx) != 1 {
+			t.Fatalf("LookupMX(a.example) = %v, %v", mx, err)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := cache.LookupMX(ctx, "missing.example"); err == nil {
+			t.Fatal("LookupMX(missing.example) succeeded")
+		}
+	}
+	if got := upstream.mxCalls.Load(); got != 2 {
+		t.Errorf("upstream MX calls = %d, want 2 (positive and negative answers cached)", got)
+	}
+	if s := cache.Stats(); s.Hits != 3 || s.Misses != 2 {
+		t.Errorf("stats = %+v, want 3 hits / 2 misses", s)
+	}
+
+	// A third name evicts the least recently used entry (a.example)
+	cache.LookupMX(ctx, "missing.example")
+	cache.LookupMX(ctx, "other.example")
+	cache.LookupMX(ctx, "a.example")
+	if got := upstream.mxCalls.Load(); got != 4 {
+		t.Errorf("upstream MX calls after eviction = %d, want 4", got)
+	}
+}
+
+func TestCachingResolverExpiry(t *testing.T) {
+	upstream := &countingResolver{FakeResolver: NewFakeResolver().AddMX("a.example", "mx.a.example.", 10)}
+	cache := NewCachingResolver(upstream, CacheOptions{DefaultTTL: 20 * time.Millisecond})
+	ctx := context.Background()
+
+	cache.LookupMX(ctx, "a.example")
+	cache.LookupMX(ctx, "a.example")
+	time.Sleep(30 * time.Millisecond)
+	cache.LookupMX(ctx, "a.example")
+	if got := upstream.mxCalls.Load(); got != 2 {
+		t.Errorf("upstream MX calls = %d, want 2", got)
+	}
+}
+
+// ttlResolver reports a fixed TTL for every MX answer.
+type ttlResolver struct {
+	*countingResolver
+	ttl time.Duration
+}
+
+func (r *ttlResolver) LookupMXTTL(ctx context.Context, name string) ([]*net.MX, time.Duration, error) {
+	mx, err := r.LookupMX(ctx, name)
+	return mx, r.ttl, err
+}
+
+func (r *ttlResolver) LookupHostTTL(ctx context.Context, name string) ([]string, time.Duration, error) {
+	hosts, err := r.LookupHost(ctx, name)
+	return hosts, r.ttl, err
+}
+
+func (r *ttlResolver) LookupTXTTTL(ctx context.Context, name string) ([]string, time.Duration, error) {
+	txt, err := r.LookupTXT(ctx, name)
+	return txt, r.ttl, err
+}
+
+func TestCachingResolverZeroTTL(t *testing.T) {
+	tests := []struct {
+		ttl   time.Duration
+		calls int32
+	}{
+		{0, 2},          // "do not cache"
+		{UnknownTTL, 1}, // kept for DefaultTTL
+		{time.Minute, 1},
+	}
+	for _, tt := range tests {
+		upstream := &ttlResolver{countingResolver: &countingResolver{FakeResolver: NewFakeResolver().AddMX("a.example", "mx.a.example.", 10)}, ttl: tt.ttl}
+		cache := NewCachingResolver(upstream, DefaultCacheOptions())
+		cache.LookupMX(context.Background(), "a.example")
+		cache.LookupMX(context.Background(), "a.example")
+		if got := upstream.mxCalls.Load(); got != tt.calls {
+			t.Errorf("TTL %v: upstream MX calls = %d, want %d", tt.ttl, got, tt.calls)
+		}
+	}
+}
+
+// gatedResolver holds MX lookups until release is closed.
+type gatedResolver struct {
+	*FakeResolver
+	started chan struct{}
+	release chan struct{}
+}
+
+func (g *gatedResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	close(g.started)
+	select {
+	case <-g.release:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return g.FakeResolver.LookupMX(ctx, name)
+}
+
+func TestCachingResolverCallerCancel(t *testing.T) {
+	upstream := &gatedResolver{
+		FakeResolver: NewFakeResolver().AddMX("a.example", "mx.a.example.", 10),
+		started:      make(chan struct{}),
+		release:      make(chan struct{}),
+	}
+	cache := NewCachingResolver(upstream, DefaultCacheOptions())
+
+	first, cancel := context.WithCancel(context.Background())
+	firstErr := make(chan error)
+	go func() {
+		_, err := cache.LookupMX(first, "a.example")
+		firstErr <- err
+	}()
+	<-upstream.started
+
+	second := make(chan error)
+	go func() {
+		mx, err := cache.LookupMX(context.Background(), "a.example")
+		if err == nil && len(mx) != 1 {
+			err = errors.New("no records")
+		}
+		second <- err
+	}()
+
+	// The caller that started the query leaves; the one waiting must not
+	// inherit its cancellation
+	cancel()
+	if err := <-firstErr; !errors.Is(err, context.Canceled) {
+		t.Errorf("cancelled caller error = %v, want context.Canceled", err)
+	}
+	close(upstream.release)
+	if err := <-second; err != nil {
+		t.Errorf("waiting caller error = %v", err)
+	}
+}
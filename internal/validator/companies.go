@@ -0,0 +1,89 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Company is a node of the company graph. Employee domains classify an
+// address as corporate; brand domains are consumer services the company
+// runs (gmail.com for Google) and keep their own classification, but still
+// report the owning company.
+type Company struct {
+	ID              string   `json:"id"`
+	Name            string   `json:"name"`
+	Parent          string   `json:"parent,omitempty"` // ID of the parent company
+	EmployeeDomains []string `json:"employee_domains"` // the first is the canonical corporate domain
+	BrandDomains    []string `json:"brand_domains,omitempty"`
+}
+
+var defaultCompanies = []Company{
+	{ID: "google", Name: "Google", EmployeeDomains: []string{"google.com"}, BrandDomains: []string{"gmail.com", "googlemail.com"}},
+	{ID: "microsoft", Name: "Microsoft", EmployeeDomains: []string{"microsoft.com"}, BrandDomains: []string{"outlook.com", "hotmail.com", "live.com"}},
+	{ID: "github", Name: "GitHub", Parent: "microsoft", EmployeeDomains: []string{"github.com"}},
+	{ID: "linkedin", Name: "LinkedIn", Parent: "microsoft", EmployeeDomains: []string{"linkedin.com"}},
+	{ID: "apple", Name: "Apple", EmployeeDomains: []string{"apple.com"}, BrandDomains: []string{"icloud.com", "me.com", "mac.com"}},
+	{ID: "meta", Name: "Meta", EmployeeDomains: []string{"meta.com", "fb.com", "facebook.com", "instagram.com", "whatsapp.com"}},
+	{ID: "amazon", Name: "Amazon", EmployeeDomains: []string{"amazon.com"}},
+	{ID: "yandex", Name: "Yandex", EmployeeDomains: []string{"yandex-team.ru", "yandex-team.com"}, BrandDomains: []string{"yandex.ru", "yandex.com", "ya.ru"}},
+	{ID: "bytedance", Name: "ByteDance", EmployeeDomains: []string{"bytedance.com", "tiktok.com"}},
+	{ID: "spotify", Name: "Spotify", EmployeeDomains: []string{"spotify.com"}},
+	{ID: "netflix", Name: "Netflix", EmployeeDomains: []string{"netflix.com"}},
+	{ID: "adobe", Name: "Adobe", EmployeeDomains: []string{"adobe.com"}},
+	{ID: "salesforce", Name: "Salesforce", EmployeeDomains: []string{"salesforce.com"}},
+	{ID: "slack", Name: "Slack", Parent: "salesforce", EmployeeDomains: []string{"slack.com"}},
+	{ID: "zoom", Name: "Zoom", EmployeeDomains: []string{"zoom.us"}},
+	{ID: "dropbox", Name: "Dropbox", EmployeeDomains: []string{"dropbox.com"}},
+	{ID: "twitter", Name: "Twitter", EmployeeDomains: []string{"twitter.com", "x.com"}},
+}
+
+// DefaultCompanies returns a copy of the built-in company graph.
+func DefaultCompanies() []Company {
+	companies := make([]Company, len(defaultCompanies))
+	for i, c := range defaultCompanies {
+		c.EmployeeDomains = append([]string(nil), c.EmployeeDomains...)
+		c.BrandDomains = append([]string(nil), c.BrandDomains...)
+		companies[i] = c
+	}
+	return companies
+}
+
+// LoadCompanies decodes a JSON array of companies.
+func LoadCompanies(r io.Reader) ([]Company, error) {
+	var companies []Company
+	if err := json.NewDecoder(r).Decode(&companies); err != nil {
+		return nil, fmt.Errorf("failed to decode companies: %w", err)
+	}
+	for i, c := range companies {
+		if c.ID == "" || len(c.EmployeeDomains) == 0 {
+			return nil, fmt.Errorf("company %d: id and employee_domains are required", i)
+		}
+	}
+	return companies, nil
+}
+
+// companyIndex maps employee and brand domains to their company.
+func companyIndex(companies []Company) map[string]*Company {
+	companies = append([]Company(nil), companies...)
+	index := make(map[string]*Company)
+	for i := range companies {
+		c := &companies[i]
+		for _, d := range append(append([]string(nil), c.EmployeeDomains...), c.BrandDomains...) {
+			if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+				index[d] = c
+			}
+		}
+	}
+	return index
+}
+
+// company returns the company owning host or, failing that, its registrable
+// domain.
+func (d *dataset) company(host, registrable string) *Company {
+	if c, ok := d.companies[host]; ok {
+		return c
+	}
+	return d.companies[registrable]
+}
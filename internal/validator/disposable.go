@@ -0,0 +1,285 @@
+package validator
+
+import (
+	"context"
+	"log"
+	"math"
+	"net"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Disposable heuristic signals and how strongly each one alone suggests a
+// throwaway domain. Signals are combined as independent evidence, so two
+// weak signals count for more than either one.
+const (
+	SignalDisposableMX   = "disposable_mx"   // an MX host belongs to a known disposable service
+	SignalSharedMX       = "shared_mx"       // an MX host also serves a listed disposable domain
+	SignalDisposableNS   = "disposable_ns"   // the zone is served by a disposable service's nameservers
+	SignalSuspiciousName = "suspicious_name" // the name reads like a throwaway service
+	SignalWildcardDNS    = "wildcard_dns"    // any subdomain has mail records
+	SignalCatchAll       = "catch_all"       // the SMTP probe found a catch-all mailbox
+)
+
+// A shared mail host alone stays below the threshold: it may be a hosting
+// provider that also serves legitimate domains.
+var disposableSignalWeights = map[string]float64{
+	SignalDisposableMX:   0.9,
+	SignalSharedMX:       0.6,
+	SignalDisposableNS:   0.8,
+	SignalSuspiciousName: 0.4,
+	SignalWildcardDNS:    0.3,
+	SignalCatchAll:       0.3,
+}
+
+// disposableThreshold is the confidence from which an unlisted domain is
+// reported as likely disposable instead of corporate.
+const disposableThreshold = 0.7
+
+// suspiciousTokens are words common among throwaway service names and rare
+// in company names. They are matched against whole words of the name,
+// optionally followed by one of mailWords, so "temp" flags temp-mail and
+// tempinbox but not tempur.
+var suspiciousTokens = []string{
+	"temp", "tmp", "trash", "throwaway", "throwam", "burner", "disposable", "discard",
+	"fake", "spam", "junk", "minutemail", "guerrilla", "dropmail", "nospam",
+	"mailinator", "yopmail", "sharklasers",
+}
+
+var mailWords = []string{"mail", "email", "inbox", "box", "mailbox"}
+
+// disposableCheck is the evidence that an unlisted domain is disposable.
+type disposableCheck struct {
+	confidence float64
+	signals    []disposableSignal // heaviest first
+}
+
+type disposableSignal struct {
+	code   string
+	reason string // human-readable detail
+}
+
+func (c *disposableCheck) add(code, reason string) {
+	c.signals = append(c.signals, disposableSignal{code: code, reason: reason})
+}
+
+func (c *disposableCheck) flagged() bool {
+	return c.confidence >= disposableThreshold
+}
+
+// checkDisposable gathers the heuristic signals for a domain that matched no
+// list but can receive mail. mxRecords are its usable MX records. The
+// nameserver lookup only runs when DISPOSABLE_NS is set or another signal
+// fired, and the wildcard probe only when it could flag the domain, so most
+// domains cost no extra queries.
+func (d *dataset) checkDisposable(ctx context.Context, r Resolver, domain, registrable string, mxRecords []*net.MX, catchAll bool) *disposableCheck {
+	c := &disposableCheck{}
+
+	shared := ""
+	for _, mx := range mxRecords {
+		host := mxTarget(mx)
+		if service := d.disposableService(host, d.disposableMX); service != "" {
+			c.add(SignalDisposableMX, "MX host "+host+" belongs to "+service)
+			shared = ""
+			break
+		}
+		if listed := d.disposableHosts[host]; listed != "" && shared == "" {
+			shared = "MX host " + host + " also serves " + listed
+		}
+	}
+	if shared != "" {
+		c.add(SignalSharedMX, shared)
+	}
+
+	zone := registrable
+	if zone == "" {
+		zone = domain
+	}
+	if token := suspiciousName(zone); token != "" {
+		c.add(SignalSuspiciousName, "name contains \""+token+"\"")
+	}
+	if catchAll {
+		c.add(SignalCatchAll, "every mailbox is accepted")
+	}
+
+	if len(c.signals) > 0 || len(d.disposableNS) > 0 {
+		ns, _ := lookupNS(ctx, r, zone)
+		for _, n := range ns {
+			host := strings.ToLower(strings.TrimSuffix(n.Host, "."))
+			if service := d.disposableService(host, d.disposableNS); service != "" {
+				c.add(SignalDisposableNS, "nameserver "+host+" belongs to "+service)
+				break
+			}
+		}
+	}
+	c.score()
+
+	if !c.flagged() && combineEvidence(c.confidence, disposableSignalWeights[SignalWildcardDNS]) >= disposableThreshold &&
+		hasWildcardMX(ctx, r, domain) {
+		c.add(SignalWildcardDNS, "any subdomain has MX records")
+		c.score()
+	}
+	return c
+}
+
+// score orders the signals and combines them as independent evidence.
+func (c *disposableCheck) score() {
+	sort.SliceStable(c.signals, func(i, j int) bool {
+		return disposableSignalWeights[c.signals[i].code] > disposableSignalWeights[c.signals[j].code]
+	})
+	confidence := 0.0
+	for _, s := range c.signals {
+		confidence = combineEvidence(confidence, disposableSignalWeights[s.code])
+	}
+	c.confidence = math.Round(confidence*100) / 100
+}
+
+// combineEvidence returns the probability that at least one of two
+// independent signals is right.
+func combineEvidence(a, b float64) float64 {
+	return 1 - (1-a)*(1-b)
+}
+
+func (c *disposableCheck) codes() []string {
+	codes := make([]string, len(c.signals))
+	for i, s := range c.signals {
+		codes[i] = s.code
+	}
+	return codes
+}
+
+func (c *disposableCheck) reason() string {
+	reasons := make([]string, len(c.signals))
+	for i, s := range c.signals {
+		reasons[i] = s.reason
+	}
+	return strings.Join(reasons, "; ")
+}
+
+// disposableService returns the disposable domain or configured pattern a
+// mail or name server host belongs to.
+func (d *dataset) disposableService(host string, patterns []string) string {
+	for name := host; name != ""; {
+		if d.disposable[name] {
+			return name
+		}
+		_, rest, ok := strings.Cut(name, ".")
+		if !ok {
+			break
+		}
+		name = rest
+	}
+	for _, p := range patterns {
+		if matchLabels(p, host) {
+			return p
+		}
+	}
+	return ""
+}
+
+// learnConcurrency bounds the MX lookups of LearnDisposableMX.
+const learnConcurrency = 8
+
+// LearnDisposableMX resolves the MX hosts of every domain on the disposable
+// list, so that the shared_mx signal recognises unlisted domains served by
+// the same mail hosts. Hosts of known mailbox platforms are left out, as
+// they also serve countless legitimate domains. Domains whose lookup fails
+// are skipped. It returns the number of hosts learned.
+//
+// Once called, the hosts are learned again in the background whenever
+// SetList changes the disposable list.
+func (v *Validator) LearnDisposableMX(ctx context.Context) int {
+	v.learnMu.Lock()
+	defer v.learnMu.Unlock()
+	v.learnDisposable.Store(true)
+	v.relearnPending.Store(false)
+
+	data := v.data.Load()
+	domains := make([]string, 0, len(data.disposable))
+	for domain := range data.disposable {
+		domains = append(domains, domain)
+	}
+	sort.Strings(domains)
+
+	records := make([][]*net.MX, len(domains))
+	sem := make(chan struct{}, learnConcurrency)
+	var wg sync.WaitGroup
+	for i, domain := range domains {
+		wg.Add(1)
+		go func(i int, domain string) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			lookupCtx, cancel := withStageTimeout(ctx, v.timeouts.DNS)
+			defer cancel()
+			records[i], _ = v.resolver.LookupMX(lookupCtx, domain)
+		}(i, domain)
+	}
+	wg.Wait()
+
+	hosts := make(map[string]string)
+	for i, mx := range records {
+		for _, rec := range mx {
+			host := mxTarget(rec)
+			if host == "" || hosts[host] != "" {
+				continue
+			}
+			if hosting, gateway := data.prints.identify([]*net.MX{rec}, nil, nil); hosting != nil || gateway != nil {
+				continue
+			}
+			hosts[host] = domains[i]
+		}
+	}
+	v.update(func(d *dataset) { d.disposableHosts = hosts })
+	return len(hosts)
+}
+
+// relearnDisposableMX schedules LearnDisposableMX after a disposable list
+// change. Changes that arrive before it starts share one run, and a run
+// always reads the list as of its start, so the last change is never lost.
+func (v *Validator) relearnDisposableMX() {
+	if !v.learnDisposable.Load() || !v.relearnPending.CompareAndSwap(false, true) {
+		return
+	}
+	go func() {
+		n := v.LearnDisposableMX(context.Background())
+		log.Printf("Relearned %d mail hosts of disposable domains", n)
+	}()
+}
+
+// wildcardProbe is a subdomain label no real zone publishes. It is fixed so
+// that the probe is cached like any other query.
+const wildcardProbe = "wildcard-probe-5e2c91d7"
+
+// hasWildcardMX reports whether a made-up subdomain of domain has MX
+// records, which throwaway services use to accept mail for any name.
+func hasWildcardMX(ctx context.Context, r Resolver, domain string) bool {
+	mx, err := r.LookupMX(ctx, wildcardProbe+"."+domain)
+	return err == nil && len(mx) > 0
+}
+
+// suspiciousName returns the first suspicious token among the words of the
+// first label of the registrable domain. Hyphens and digits separate words.
+func suspiciousName(registrable string) string {
+	name, _, _ := strings.Cut(registrable, ".")
+	words := strings.FieldsFunc(name, func(ch rune) bool { return ch < 'a' || ch > 'z' })
+	for _, token := range suspiciousTokens {
+		for _, word := range words {
+			if rest, ok := strings.CutPrefix(word, token); ok && (rest == "" || slices.Contains(mailWords, rest)) {
+				return token
+			}
+		}
+	}
+	digits := 0
+	for _, ch := range name {
+		if ch >= '0' && ch <= '9' {
+			digits++
+		}
+	}
+	if digits >= 4 {
+		return "digits"
+	}
+	return ""
+}
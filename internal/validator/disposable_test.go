@@ -0,0 +1,149 @@
+package validator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDisposableHeuristics(t *testing.T) {
+	opts := DefaultOptions()
+	opts.DisposableMX = []string{"*.relay.example"}
+	opts.DisposableNS = []string{"*.throwdns.example"}
+	opts.Resolver = NewFakeResolver().
+		AddMX("acme.example", "mx.acme.example.", 10).
+		AddNS("acme.example", "ns1.dnshost.example.").
+		AddMX("shared.example", "mx.yopmail.com.", 10).
+		AddMX("relayed.example", "in.relay.example.", 10).
+		AddMX("ns.example", "mx.ns.example.", 10).
+		AddNS("ns.example", "a.throwdns.example.", "b.throwdns.example.").
+		AddMX("tempbox.example", "mx.tempbox.example.", 10).
+		AddMX(wildcardProbe+".tempbox.example", "mx.tempbox.example.", 10).
+		AddMX("temp-ns.example", "mx.temp-ns.example.", 10).
+		AddNS("temp-ns.example", "ns.throwdns.example.").
+		AddHost("mx.acme.example", "64.233.184.26").
+		AddHost("mx.yopmail.com", "64.233.184.27").
+		AddHost("in.relay.example", "64.233.184.28").
+		AddHost("mx.ns.example", "64.233.184.29").
+		AddHost("mx.tempbox.example", "64.233.184.30").
+		AddHost("mx.temp-ns.example", "64.233.184.31")
+	v := New(opts)
+
+	tests := []struct {
+		email        string
+		confidence   float64
+		signals      []string
+		providerType string
+		reason       string
+	}{
+		{"jane@acme.example", 0, nil, "corporate", ""},
+		{"eve@yopmail.com", 1, nil, "disposable", ReasonDisposableListMatch},
+		{"eve@shared.example", 0.9, []string{SignalDisposableMX}, "disposable", ReasonDisposableHeuristic},
+		{"eve@relayed.example", 0.9, []string{SignalDisposableMX}, "disposable", ReasonDisposableHeuristic},
+		{"eve@ns.example", 0.8, []string{SignalDisposableNS}, "disposable", ReasonDisposableHeuristic},
+		// A weak signal alone leaves the domain corporate, and the wildcard
+		// probe could not change that
+		{"eve@tempbox.example", 0.4, []string{SignalSuspiciousName}, "corporate", ""},
+		{"eve@temp-ns.example", 0.88, []string{SignalDisposableNS, SignalSuspiciousName}, "disposable", ReasonDisposableHeuristic},
+	}
+	for _, tt := range tests {
+		r := v.Validate(tt.email)
+		if r.DisposableConfidence != tt.confidence || !reflect.DeepEqual(r.DisposableSignals, tt.signals) || r.ProviderType != tt.providerType {
+			t.Errorf("%s: confidence %v signals %v type %s, want %v %v %s",
+				tt.email, r.DisposableConfidence, r.DisposableSignals, r.ProviderType, tt.confidence, tt.signals, tt.providerType)
+		}
+		if (tt.confidence > 0) != (r.DisposableReason != "") {
+			t.Errorf("%s: disposable_reason %q", tt.email, r.DisposableReason)
+		}
+		if tt.reason != "" && (len(r.Reasons) == 0 || r.Reasons[0] != tt.reason) {
+			t.Errorf("%s: reasons %v, want %s first", tt.email, r.Reasons, tt.reason)
+		}
+		if r.IsDisposable != (tt.reason == ReasonDisposableListMatch) {
+			t.Errorf("%s: is_disposable = %v", tt.email, r.IsDisposable)
+		}
+		if r.Valid == (tt.providerType == "disposable") {
+			t.Errorf("%s: valid = %v", tt.email, r.Valid)
+		}
+		if tt.reason == ReasonDisposableHeuristic && (r.ClassifiedBy != RuleDisposableHeuristic || r.Confidence != tt.confidence) {
+			t.Errorf("%s: classified_by %q confidence %v", tt.email, r.ClassifiedBy, r.Confidence)
+		}
+	}
+}
+
+func TestLearnDisposableMX(t *testing.T) {
+	opts := DefaultOptions()
+	opts.DisposableDomains = []string{"burner.example", "hosted-burner.example"}
+	opts.Resolver = NewFakeResolver().
+		AddMX("burner.example", "in.burnerhost.example.", 10).
+		AddMX("hosted-burner.example", "aspmx.l.google.com.", 10).
+		AddMX("fresh.example", "in.burnerhost.example.", 10).
+		AddMX("trashbox.example", "in.burnerhost.example.", 10).
+		AddMX("workspace.example", "aspmx.l.google.com.", 10).
+		AddMX("later.example", "mx.laterhost.example.", 10).
+		AddMX("sibling.example", "mx.laterhost.example.", 10).
+		AddHost("in.burnerhost.example", "64.233.184.26").
+		AddHost("mx.laterhost.example", "64.233.184.27").
+		AddHost("aspmx.l.google.com", "142.250.27.26")
+	v := New(opts)
+
+	if r := v.Validate("eve@fresh.example"); r.DisposableConfidence != 0 {
+		t.Errorf("fresh.example flagged before learning: %v", r.DisposableSignals)
+	}
+	// Google's hosts serve far more than the disposable domain on them
+	if n := v.LearnDisposableMX(context.Background()); n != 1 {
+		t.Errorf("learned %d hosts, want 1", n)
+	}
+
+	tests := []struct {
+		email        string
+		confidence   float64
+		signals      []string
+		providerType string
+	}{
+		// The host may be a hosting provider's, so sharing it is not enough
+		{"eve@fresh.example", 0.6, []string{SignalSharedMX}, "corporate"},
+		{"eve@trashbox.example", 0.76, []string{SignalSharedMX, SignalSuspiciousName}, "disposable"},
+		{"jane@workspace.example", 0, nil, "corporate"},
+	}
+	for _, tt := range tests {
+		r := v.Validate(tt.email)
+		if r.DisposableConfidence != tt.confidence || !reflect.DeepEqual(r.DisposableSignals, tt.signals) || r.ProviderType != tt.providerType {
+			t.Errorf("%s: confidence %v signals %v type %s, want %v %v %s",
+				tt.email, r.DisposableConfidence, r.DisposableSignals, r.ProviderType, tt.confidence, tt.signals, tt.providerType)
+		}
+	}
+
+	// Changing the disposable list learns its hosts again
+	v.SetList(DomainList{Kind: ListDisposable, Source: "admin", Domains: map[string]string{"later.example": ""}})
+	deadline := time.Now().Add(2 * time.Second)
+	for v.data.Load().disposableHosts["mx.laterhost.example"] == "" && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if r := v.Validate("eve@sibling.example"); !reflect.DeepEqual(r.DisposableSignals, []string{SignalSharedMX}) {
+		t.Errorf("sibling.example: signals %v after the list change, want [%s]", r.DisposableSignals, SignalSharedMX)
+	}
+}
+
+func TestSuspiciousName(t *testing.T) {
+	tests := []struct {
+		domain string
+		want   string
+	}{
+		{"acme.com", ""},
+		{"tempinbox.net", "temp"},
+		{"temp-mail.org", "temp"},
+		{"tempur.com", ""},
+		{"contemporary.com", ""},
+		{"spamassassin.org", ""},
+		{"10minutemail.com", "minutemail"},
+		{"my-trash-mail.co.uk", "trash"},
+		{"mail20240101.com", "digits"},
+		{"web3.io", ""},
+	}
+	for _, tt := range tests {
+		if got := suspiciousName(tt.domain); got != tt.want {
+			t.Errorf("suspiciousName(%q) = %q, want %q", tt.domain, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package validator
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// TTLResolver is implemented by resolvers that can report how long an
+// answer may be cached. The returned TTL is the smallest TTL in the answer,
+// or UnknownTTL when the answer carries none. A TTL of 0 means the answer
+// must not be cached.
+type TTLResolver interface {
+	Resolver
+	LookupMXTTL(ctx context.Context, name string) ([]*net.MX, time.Duration, error)
+	LookupHostTTL(ctx context.Context, name string) ([]string, time.Duration, error)
+	LookupTXTTTL(ctx context.Context, name string) ([]string, time.Duration, error)
+}
+
+// UnknownTTL is reported for answers without a TTL; CachingResolver keeps
+// them for its DefaultTTL.
+const UnknownTTL time.Duration = -1
+
+// DNSClient queries a single nameserver directly. Unlike net.Resolver it
+// exposes record TTLs, which lets CachingResolver honour them.
+type DNSClient struct {
+	server  string
+	timeout time.Duration // per query, when the context allows longer
+}
+
+// SystemNameserver returns the first nameserver of /etc/resolv.conf, or ""
+// when there is none, so the system's resolver can be queried directly.
+func SystemNameserver() string {
+	f, err := os.Open("/etc/resolv.conf")
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	return firstNameserver(f)
+}
+
+// firstNameserver returns the address of the first nameserver line of a
+// resolv.conf file.
+func firstNameserver(r io.Reader) string {
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) >= 2 && fields[0] == "nameserver" {
+			return fields[1]
+		}
+	}
+	return ""
+}
+
+// ednsPayload is the UDP response size advertised with EDNS0, small enough
+// to avoid IP fragmentation.
+const ednsPayload = 1232
+
+func NewDNSClient(server string) *DNSClient {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &DNSClient{server: server, timeout: 5 * time.Second}
+}
+
+func (c *DNSClient) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	mx, _, err := c.LookupMXTTL(ctx, name)
+	return mx, err
+}
+
+func (c *DNSClient) LookupHost(ctx context.Context, name string) ([]string, error) {
+	hosts, _, err := c.LookupHostTTL(ctx, name)
+	return hosts, err
+}
+
+func (c *DNSClient) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	txt, _, err := c.LookupTXTTTL(ctx, name)
+	return txt, err
+}
+
+func (c *DNSClient) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	answers, err := c.exchange(ctx, name, dnsmessage.TypeNS)
+	if err != nil {
+		return nil, err
+	}
+	var ns []*net.NS
+	for _, a := range answers {
+		if r, ok := a.Body.(*dnsmessage.NSResource); ok {
+			ns = append(ns, &net.NS{Host: r.NS.String()})
+		}
+	}
+	if len(ns) == 0 {
+		return nil, notFound(name)
+	}
+	return ns, nil
+}
+
+func (c *DNSClient) LookupMXTTL(ctx context.Context, name string) ([]*net.MX, time.Duration, error) {
+	answers, err := c.exchange(ctx, name, dnsmessage.TypeMX)
+	if err != nil {
+		return nil, 0, err
+	}
+	var mx []*net.MX
+	ttl := noTTL
+	for _, a := range answers {
+		if r, ok := a.Body.(*dnsmessage.MXResource); ok {
+			mx = append(mx, &net.MX{Host: r.MX.String(), Pref: r.Pref})
+			ttl = minTTL(ttl, a.Header.TTL)
+		}
+	}
+	if len(mx) == 0 {
+		return nil, 0, notFound(name)
+	}
+	return mx, seconds(ttl), nil
+}
+
+func (c *DNSClient) LookupHostTTL(ctx context.Context, name string) ([]string, time.Duration, error) {
+	var hosts []string
+	ttl := noTTL
+	var firstErr error
+	for _, qtype := range []dnsmessage.Type{dnsmessage.TypeA, dnsmessage.TypeAAAA} {
+		answers, err := c.exchange(ctx, name, qtype)
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		for _, a := range answers {
+			switch r := a.Body.(type) {
+			case *dnsmessage.AResource:
+				hosts = append(hosts, net.IP(r.A[:]).String())
+				ttl = minTTL(ttl, a.Header.TTL)
+			case *dnsmessage.AAAAResource:
+				hosts = append(hosts, net.IP(r.AAAA[:]).String())
+				ttl = minTTL(ttl, a.Header.TTL)
+			}
+		}
+	}
+	if len(hosts) == 0 {
+		if firstErr == nil {
+			firstErr = notFound(name)
+		}
+		return nil, 0, firstErr
+	}
+	return hosts, seconds(ttl), nil
+}
+
+func (c *DNSClient) LookupTXTTTL(ctx context.Context, name string) ([]string, time.Duration, error) {
+	answers, err := c.exchange(ctx, name, dnsmessage.TypeTXT)
+	if err != nil {
+		return nil, 0, err
+	}
+	var txt []string
+	ttl := noTTL
+	for _, a := range answers {
+		if r, ok := a.Body.(*dnsmessage.TXTResource); ok {
+			txt = append(txt, strings.Join(r.TXT, ""))
+			ttl = minTTL(ttl, a.Header.TTL)
+		}
+	}
+	if len(txt) == 0 {
+		return nil, 0, notFound(name)
+	}
+	return txt, seconds(ttl), nil
+}
+
+// exchange sends a single question over UDP, retrying over TCP when the
+// answer is truncated. NXDOMAIN and empty answers are reported as not-found
+// DNS errors.
+func (c *DNSClient) exchange(ctx context.Context, name string, qtype dnsmessage.Type) ([]dnsmessage.Resource, error) {
+	fqdn := name
+	if !strings.HasSuffix(fqdn, ".") {
+		fqdn += "."
+	}
+	qname, err := dnsmessage.NewName(fqdn)
+	if err != nil {
+		return nil, &net.DNSError{Err: err.Error(), Name: name}
+	}
+	var id [2]byte
+	if _, err := rand.Read(id[:]); err != nil {
+		return nil, err
+	}
+	var opt dnsmessage.ResourceHeader
+	if err := opt.SetEDNS0(ednsPayload, dnsmessage.RCodeSuccess, false); err != nil {
+		return nil, err
+	}
+	query := dnsmessage.Message{
+		Header:      dnsmessage.Header{ID: binary.BigEndian.Uint16(id[:]), RecursionDesired: true},
+		Questions:   []dnsmessage.Question{{Name: qname, Type: qtype, Class: dnsmessage.ClassINET}},
+		Additionals: []dnsmessage.Resource{{Header: opt, Body: &dnsmessage.OPTResource{}}},
+	}
+	packed, err := query.Pack()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.roundTrip(ctx, "udp", packed, &query)
+	if err == nil && resp.Header.Truncated {
+		resp, err = c.roundTrip(ctx, "tcp", packed, &query)
+	}
+	if err != nil {
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		return nil, &net.DNSError{Err: err.Error(), Name: name, Server: c.server, IsTimeout: isTimeout(err)}
+	}
+
+	switch resp.Header.RCode {
+	case dnsmessage.RCodeSuccess:
+	case dnsmessage.RCodeNameError:
+		return nil, notFound(name)
+	default:
+		return nil, &net.DNSError{Err: "server misbehaving: " + resp.Header.RCode.String(), Name: name, Server: c.server, IsTemporary: true}
+	}
+	return resp.Answers, nil
+}
+
+// roundTrip sends packed and returns the response to query. Over UDP,
+// packets that do not answer query are dropped, so a spoofed or stale
+// response cannot end the lookup; the wait is bounded by the context and
+// c.timeout.
+func (c *DNSClient) roundTrip(ctx context.Context, network string, packed []byte, query *dnsmessage.Message) (*dnsmessage.Message, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, network, c.server)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	deadline := time.Now().Add(c.timeout)
+	if dl, ok := ctx.Deadline(); ok && dl.Before(deadline) {
+		deadline = dl
+	}
+	conn.SetDeadline(deadline)
+
+	if network == "tcp" {
+		msg := make([]byte, 2+len(packed))
+		binary.BigEndian.PutUint16(msg, uint16(len(packed)))
+		copy(msg[2:], packed)
+		if _, err := conn.Write(msg); err != nil {
+			return nil, err
+		}
+		var length [2]byte
+		if _, err := io.ReadFull(conn, length[:]); err != nil {
+			return nil, err
+		}
+		buf := make([]byte, binary.BigEndian.Uint16(length[:]))
+		if _, err := io.ReadFull(conn, buf); err != nil {
+			return nil, err
+		}
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf); err != nil {
+			return nil, errors.New("malformed response: " + err.Error())
+		}
+		if !answersQuery(&resp, query) {
+			return nil, errors.New("response does not match the query")
+		}
+		return &resp, nil
+	}
+
+	if _, err := conn.Write(packed); err != nil {
+		return nil, err
+	}
+	buf := make([]byte, ednsPayload)
+	for {
+		n, err := conn.Read(buf)
+		if err != nil {
+			return nil, err
+		}
+		var resp dnsmessage.Message
+		if err := resp.Unpack(buf[:n]); err == nil && answersQuery(&resp, query) {
+			return &resp, nil
+		}
+	}
+}
+
+// answersQuery reports whether resp is a response to query: same ID and
+// the same single question.
+func answersQuery(resp, query *dnsmessage.Message) bool {
+	if !resp.Header.Response || resp.Header.ID != query.Header.ID || len(resp.Questions) != 1 {
+		return false
+	}
+	got, want := resp.Questions[0], query.Questions[0]
+	return got.Type == want.Type && got.Class == want.Class && strings.EqualFold(got.Name.String(), want.Name.String())
+}
+
+// noTTL is the starting value when taking the minimum TTL of an answer.
+const noTTL = ^uint32(0)
+
+func minTTL(cur, ttl uint32) uint32 {
+	if ttl < cur {
+		return ttl
+	}
+	return cur
+}
+
+// seconds converts the minimum TTL of an answer, which is noTTL when the
+// answer had no records.
+func seconds(ttl uint32) time.Duration {
+	if ttl == noTTL {
+		return UnknownTTL
+	}
+	return time.Duration(ttl) * time.Second
+}
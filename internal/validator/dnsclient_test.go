@@ -0,0 +1,123 @@
+package validator
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+// serveDNS answers MX queries for example.com from a local UDP socket and
+// returns NXDOMAIN for everything else. Each answer is preceded by packets
+// with the wrong ID and the wrong question, which the client must ignore,
+// and queries without EDNS0 get FORMERR.
+func serveDNS(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	go func() {
+		buf := make([]byte, 512)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			var q dnsmessage.Message
+			if err := q.Unpack(buf[:n]); err != nil {
+				continue
+			}
+			resp := dnsmessage.Message{
+				Header:    dnsmessage.Header{ID: q.Header.ID, Response: true, RCode: dnsmessage.RCodeNameError},
+				Questions: q.Questions,
+			}
+			question := q.Questions[0]
+			if len(q.Additionals) != 1 || q.Additionals[0].Header.Type != dnsmessage.TypeOPT {
+				resp.Header.RCode = dnsmessage.RCodeFormatError
+			} else if question.Name.String() == "example.com." && question.Type == dnsmessage.TypeMX {
+				resp.Header.RCode = dnsmessage.RCodeSuccess
+				resp.Answers = []dnsmessage.Resource{
+					{
+						Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeMX, Class: dnsmessage.ClassINET, TTL: 300},
+						Body:   &dnsmessage.MXResource{Pref: 10, MX: dnsmessage.MustNewName("mx1.example.com.")},
+					},
+					{
+						Header: dnsmessage.ResourceHeader{Name: question.Name, Type: dnsmessage.TypeMX, Class: dnsmessage.ClassINET, TTL: 120},
+						Body:   &dnsmessage.MXResource{Pref: 20, MX: dnsmessage.MustNewName("mx2.example.com.")},
+					},
+				}
+			}
+			spoofed := resp
+			spoofed.Header.ID++
+			out, _ := spoofed.Pack()
+			conn.WriteTo(out, addr)
+			spoofed = resp
+			spoofed.Questions = []dnsmessage.Question{{Name: dnsmessage.MustNewName("other.example."), Type: question.Type, Class: question.Class}}
+			out, _ = spoofed.Pack()
+			conn.WriteTo(out, addr)
+
+			out, _ = resp.Pack()
+			conn.WriteTo(out, addr)
+		}
+	}()
+	return conn.LocalAddr().String()
+}
+
+func TestDNSClient(t *testing.T) {
+	c := NewDNSClient(serveDNS(t))
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	mx, ttl, err := c.LookupMXTTL(ctx, "example.com")
+	if err != nil {
+		t.Fatalf("LookupMXTTL: %v", err)
+	}
+	if len(mx) != 2 || mx[0].Host != "mx1.example.com." || mx[0].Pref != 10 {
+		t.Errorf("MX = %v", mx)
+	}
+	if ttl != 120*time.Second {
+		t.Errorf("TTL = %v, want 2m0s", ttl)
+	}
+
+	_, _, err = c.LookupMXTTL(ctx, "missing.example.com")
+	if dnsErr, ok := err.(*net.DNSError); !ok || !dnsErr.IsNotFound {
+		t.Errorf("missing domain error = %v, want not-found DNSError", err)
+	}
+
+	// Without a context deadline the client's own timeout still applies
+	c = NewDNSClient(silentDNS(t))
+	c.timeout = 50 * time.Millisecond
+	if _, _, err := c.LookupMXTTL(context.Background(), "example.com"); !isTimeout(err) {
+		t.Errorf("unanswered query error = %v, want timeout", err)
+	}
+}
+
+// silentDNS reads queries and never answers.
+func silentDNS(t *testing.T) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String()
+}
+
+func TestFirstNameserver(t *testing.T) {
+	tests := []struct{ conf, want string }{
+		{"# generated\nsearch corp.example\nnameserver 10.0.0.2\nnameserver 10.0.0.3\n", "10.0.0.2"},
+		{"nameserver fe80::1%eth0\n", "fe80::1%eth0"},
+		{"options ndots:5\n", ""},
+	}
+	for _, tt := range tests {
+		if got := firstNameserver(strings.NewReader(tt.conf)); got != tt.want {
+			t.Errorf("firstNameserver(%q) = %q, want %q", tt.conf, got, tt.want)
+		}
+	}
+}
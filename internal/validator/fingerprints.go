@@ -0,0 +1,194 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// Fingerprint kinds.
+const (
+	FingerprintHosting = "hosting" // stores the mailboxes
+	FingerprintGateway = "gateway" // filters mail in front of the mailbox host
+)
+
+// Fingerprint identifies a mail platform from DNS. MX and SPF patterns are
+// host names matched on label boundaries, so "google.com" matches
+// "aspmx.l.google.com" but not "notgoogle.com"; a "*" label matches any one
+// label. Verification records are prefixes of the domain's TXT records
+// that are only published to activate mail hosting; site ownership tokens
+// such as google-site-verification are no evidence of it.
+type Fingerprint struct {
+	ID           string   `json:"id"`
+	Name         string   `json:"name"`
+	Kind         string   `json:"kind"` // see Fingerprint* constants
+	MX           []string `json:"mx,omitempty"`
+	SPFIncludes  []string `json:"spf_includes,omitempty"`
+	Verification []string `json:"verification,omitempty"`
+}
+
+var defaultFingerprints = []Fingerprint{
+	{ID: "google-workspace", Name: "Google Workspace", Kind: FingerprintHosting, MX: []string{"google.com", "googlemail.com"}, SPFIncludes: []string{"_spf.google.com"}},
+	{ID: "gmail", Name: "Gmail", Kind: FingerprintHosting, MX: []string{"gmail-smtp-in.l.google.com"}},
+	{ID: "microsoft-365", Name: "Microsoft 365", Kind: FingerprintHosting, MX: []string{"mail.protection.outlook.com", "mx.microsoft"}, SPFIncludes: []string{"spf.protection.outlook.com"}, Verification: []string{"MS="}},
+	{ID: "outlook", Name: "Outlook.com", Kind: FingerprintHosting, MX: []string{"olc.protection.outlook.com"}},
+	{ID: "zoho", Name: "Zoho Mail", Kind: FingerprintHosting, MX: []string{"zoho.com", "zoho.eu", "zoho.in", "zoho.com.au", "zoho.jp", "zohomail.com"}, SPFIncludes: []string{"zoho.com", "zoho.eu", "zoho.in", "zohomail.com"}, Verification: []string{"zoho-verification="}},
+	{ID: "proton", Name: "Proton Mail", Kind: FingerprintHosting, MX: []string{"protonmail.ch"}, SPFIncludes: []string{"_spf.protonmail.ch"}, Verification: []string{"protonmail-verification="}},
+	{ID: "fastmail", Name: "Fastmail", Kind: FingerprintHosting, MX: []string{"messagingengine.com"}, SPFIncludes: []string{"spf.messagingengine.com"}},
+	{ID: "yandex-360", Name: "Yandex 360", Kind: FingerprintHosting, MX: []string{"mx.yandex.net"}, SPFIncludes: []string{"_spf.yandex.net"}},
+	{ID: "yandex", Name: "Yandex Mail", Kind: FingerprintHosting, MX: []string{"mx.yandex.ru"}},
+	{ID: "amazon-workmail", Name: "Amazon WorkMail", Kind: FingerprintHosting, MX: []string{"inbound-smtp.*.amazonaws.com"}},
+	{ID: "yahoo", Name: "Yahoo Mail", Kind: FingerprintHosting, MX: []string{"yahoodns.net"}},
+	{ID: "icloud", Name: "iCloud Mail", Kind: FingerprintHosting, MX: []string{"mail.icloud.com"}, SPFIncludes: []string{"icloud.com"}, Verification: []string{"apple-domain="}},
+	{ID: "proofpoint", Name: "Proofpoint", Kind: FingerprintGateway, MX: []string{"pphosted.com", "ppe-hosted.com"}, SPFIncludes: []string{"pphosted.com", "ppe-hosted.com"}},
+	{ID: "mimecast", Name: "Mimecast", Kind: FingerprintGateway, MX: []string{"mimecast.com", "mimecast.co.za", "mimecast-offshore.com"}, SPFIncludes: []string{"mimecast.com"}},
+	{ID: "barracuda", Name: "Barracuda", Kind: FingerprintGateway, MX: []string{"barracudanetworks.com"}, SPFIncludes: []string{"barracudanetworks.com"}},
+}
+
+// DefaultFingerprints returns a copy of the built-in fingerprint database.
+func DefaultFingerprints() []Fingerprint {
+	prints := make([]Fingerprint, len(defaultFingerprints))
+	for i, f := range defaultFingerprints {
+		f.MX = append([]string(nil), f.MX...)
+		f.SPFIncludes = append([]string(nil), f.SPFIncludes...)
+		f.Verification = append([]string(nil), f.Verification...)
+		prints[i] = f
+	}
+	return prints
+}
+
+// LoadFingerprints decodes a JSON array of fingerprints.
+func LoadFingerprints(r io.Reader) ([]Fingerprint, error) {
+	var prints []Fingerprint
+	if err := json.NewDecoder(r).Decode(&prints); err != nil {
+		return nil, fmt.Errorf("failed to decode fingerprints: %w", err)
+	}
+	for i, f := range prints {
+		if f.ID == "" || f.Name == "" {
+			return nil, fmt.Errorf("fingerprint %d: id and name are required", i)
+		}
+		if f.Kind != FingerprintHosting && f.Kind != FingerprintGateway {
+			return nil, fmt.Errorf("fingerprint %s: kind must be %q or %q", f.ID, FingerprintHosting, FingerprintGateway)
+		}
+	}
+	return prints, nil
+}
+
+// fingerprintDB matches DNS data against the fingerprints. When several
+// patterns match a host the one with the most labels wins, so the Gmail
+// MX is not reported as Google Workspace.
+type fingerprintDB struct {
+	prints []Fingerprint
+}
+
+func newFingerprintDB(prints []Fingerprint) *fingerprintDB {
+	db := &fingerprintDB{prints: make([]Fingerprint, len(prints))}
+	for i, f := range prints {
+		f.MX = lowerAll(f.MX)
+		f.SPFIncludes = lowerAll(f.SPFIncludes)
+		f.Verification = lowerAll(f.Verification)
+		db.prints[i] = f
+	}
+	return db
+}
+
+// identify returns the mailbox host and the gateway in front of it, or
+// nil. MX records decide first; SPF includes and then verification
+// records name the host behind a gateway or when the MX is unknown.
+func (db *fingerprintDB) identify(mxRecords []*net.MX, includes, txts []string) (hosting, gateway *Fingerprint) {
+	for _, mx := range sortedMX(mxRecords) {
+		f := db.match(mxTarget(mx), func(f *Fingerprint) []string { return f.MX })
+		hosting, gateway = assign(f, hosting, gateway)
+	}
+	for _, include := range includes {
+		f := db.match(strings.ToLower(include), func(f *Fingerprint) []string { return f.SPFIncludes })
+		hosting, gateway = assign(f, hosting, gateway)
+	}
+	if hosting == nil {
+		for _, txt := range txts {
+			txt = strings.ToLower(strings.TrimSpace(txt))
+			for i := range db.prints {
+				f := &db.prints[i]
+				if f.Kind == FingerprintHosting && hasAnyPrefix(txt, f.Verification) {
+					return f, gateway
+				}
+			}
+		}
+	}
+	return hosting, gateway
+}
+
+// assign fills the slot of f's kind unless an earlier match took it.
+func assign(f, hosting, gateway *Fingerprint) (*Fingerprint, *Fingerprint) {
+	switch {
+	case f == nil:
+	case f.Kind == FingerprintHosting && hosting == nil:
+		hosting = f
+	case f.Kind == FingerprintGateway && gateway == nil:
+		gateway = f
+	}
+	return hosting, gateway
+}
+
+func (db *fingerprintDB) match(host string, patterns func(f *Fingerprint) []string) *Fingerprint {
+	var best *Fingerprint
+	bestLabels := 0
+	for i := range db.prints {
+		f := &db.prints[i]
+		for _, p := range patterns(f) {
+			if n := strings.Count(p, ".") + 1; n > bestLabels && matchLabels(p, host) {
+				best, bestLabels = f, n
+			}
+		}
+	}
+	return best
+}
+
+// matchLabels reports whether host ends with pattern on a label boundary.
+func matchLabels(pattern, host string) bool {
+	pl := strings.Split(pattern, ".")
+	hl := strings.Split(strings.TrimSuffix(host, "."), ".")
+	if len(hl) < len(pl) {
+		return false
+	}
+	hl = hl[len(hl)-len(pl):]
+	for i, label := range pl {
+		if label != "*" && label != hl[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// spfIncludes returns the include: and redirect= domains of an SPF record.
+func spfIncludes(record string) []string {
+	var domains []string
+	for _, term := range strings.Fields(strings.ToLower(record)) {
+		term = strings.TrimLeft(term, "+-~?")
+		if d, ok := strings.CutPrefix(term, "include:"); ok {
+			domains = append(domains, d)
+		} else if d, ok := strings.CutPrefix(term, "redirect="); ok {
+			domains = append(domains, d)
+		}
+	}
+	return domains
+}
+
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if strings.HasPrefix(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+func lowerAll(list []string) []string {
+	out := make([]string, len(list))
+	for i, s := range list {
+		out[i] = strings.ToLower(strings.TrimSpace(s))
+	}
+	return out
+}
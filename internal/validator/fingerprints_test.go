@@ -0,0 +1,108 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprints(t *testing.T) {
+	res := NewFakeResolver().
+		AddMX("ws.example", "aspmx.l.google.com.", 1).
+		AddMX("ws.example", "alt1.aspmx.l.google.com.", 5).
+		AddMX("m365.example", "m365-example.mail.protection.outlook.com.", 0).
+		AddMX("delivery.example", "mx.delivery.example.", 10).
+		AddMX("pp.example", "mx0a-001.pphosted.com.", 10).
+		AddTXT("pp.example", "v=spf1 include:spf.protection.outlook.com include:pphosted.com -all").
+		AddMX("mc.example", "eu-smtp-inbound-1.mimecast.com.", 10).
+		AddTXT("mc.example", "MS=ms12345678", "v=spf1 -all").
+		AddMX("site.example", "mail.site.example.", 10).
+		AddTXT("site.example", "google-site-verification=abc123", "yandex-verification: 0123abcd").
+		AddMX("wm.example", "inbound-smtp.eu-west-1.amazonaws.com.", 10).
+		AddMX("zoho.example", "mx.zoho.eu.", 10).
+		AddMX("self.example", "mail.self.example.", 10).
+		AddTXT("self.example", "v=spf1 mx include:spf.messagingengine.com ~all").
+		AddMX("y360.example", "mx.yandex.net.", 10).
+		AddMX("notgoogle.example", "mx.notgoogle.com.", 10).
+		AddHost("aspmx.l.google.com", "142.250.27.27").
+		AddHost("alt1.aspmx.l.google.com", "142.250.27.28").
+		AddHost("m365-example.mail.protection.outlook.com", "52.101.68.1").
+		AddHost("mx.delivery.example", "64.233.184.26").
+		AddHost("mx0a-001.pphosted.com", "148.163.156.1").
+		AddHost("eu-smtp-inbound-1.mimecast.com", "91.220.42.1").
+		AddHost("inbound-smtp.eu-west-1.amazonaws.com", "52.94.124.9").
+		AddHost("mx.zoho.eu", "185.20.209.1").
+		AddHost("mail.self.example", "64.233.184.27").
+		AddHost("mail.site.example", "64.233.184.29").
+		AddHost("mx.yandex.net", "77.88.21.249").
+		AddHost("mx.notgoogle.com", "64.233.184.28")
+
+	opts := DefaultOptions()
+	opts.Resolver = res
+	v := New(opts)
+
+	tests := []struct {
+		domain  string
+		hosting string
+		gateway string
+	}{
+		{"ws.example", "Google Workspace", ""},
+		{"m365.example", "Microsoft 365", ""},
+		{"delivery.example", "", ""},
+		{"pp.example", "Microsoft 365", "Proofpoint"},
+		{"mc.example", "Microsoft 365", "Mimecast"},
+		{"site.example", "", ""},
+		{"wm.example", "Amazon WorkMail", ""},
+		{"zoho.example", "Zoho Mail", ""},
+		{"self.example", "Fastmail", ""},
+		{"y360.example", "Yandex 360", ""},
+		{"notgoogle.example", "", ""},
+	}
+	for _, tt := range tests {
+		r := v.Validate("a@" + tt.domain)
+		if r.HostingProvider != tt.hosting || r.Gateway != tt.gateway {
+			t.Errorf("%s: hosting %q gateway %q, want %q %q", tt.domain, r.HostingProvider, r.Gateway, tt.hosting, tt.gateway)
+		}
+		want := tt.hosting
+		if want == "" {
+			want = tt.domain
+		}
+		if r.ProviderName != want {
+			t.Errorf("%s: provider_name %q, want %q", tt.domain, r.ProviderName, want)
+		}
+	}
+}
+
+func TestFingerprintMostSpecificMX(t *testing.T) {
+	db := newFingerprintDB(DefaultFingerprints())
+	tests := []struct {
+		host string
+		want string
+	}{
+		{"gmail-smtp-in.l.google.com", "gmail"},
+		{"aspmx.l.google.com", "google-workspace"},
+		{"hotmail-com.olc.protection.outlook.com", "outlook"},
+		{"contoso-com.mail.protection.outlook.com", "microsoft-365"},
+		{"outbound-smtp.eu-west-1.amazonaws.com", ""},
+		{"google.com.evil.example", ""},
+	}
+	for _, tt := range tests {
+		f := db.match(tt.host, func(f *Fingerprint) []string { return f.MX })
+		got := ""
+		if f != nil {
+			got = f.ID
+		}
+		if got != tt.want {
+			t.Errorf("match(%s) = %q, want %q", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestLoadFingerprints(t *testing.T) {
+	prints, err := LoadFingerprints(strings.NewReader(`[{"id": "acme-mail", "name": "Acme Mail", "kind": "hosting", "mx": ["mx.acmemail.example"]}]`))
+	if err != nil || len(prints) != 1 || prints[0].MX[0] != "mx.acmemail.example" {
+		t.Fatalf("LoadFingerprints = %+v, %v", prints, err)
+	}
+	if _, err := LoadFingerprints(strings.NewReader(`[{"id": "x", "name": "X", "kind": "relay"}]`)); err == nil {
+		t.Error("unknown kind accepted")
+	}
+}
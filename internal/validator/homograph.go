@@ -0,0 +1,85 @@
+package validator
+
+import (
+	"strings"
+	"unicode"
+)
+
+// confusables maps non-Latin characters to the Latin letters they are
+// visually indistinguishable from in common fonts (a subset of the Unicode
+// confusables table that covers the scripts seen in IDN phishing).
+var confusables = map[rune]rune{
+	// Cyrillic
+	'а': 'a', 'в': 'b', 'с': 'c', 'ԁ': 'd', 'е': 'e', 'ё': 'e', 'һ': 'h', 'і': 'i', 'ї': 'i',
+	'ј': 'j', 'к': 'k', 'ӏ': 'l', 'м': 'm', 'п': 'n', 'о': 'o', 'р': 'p', 'ԛ': 'q', 'г': 'r',
+	'ѕ': 's', 'т': 't', 'ц': 'u', 'ѵ': 'v', 'ԝ': 'w', 'х': 'x', 'у': 'y', 'ү': 'y',
+	// Greek
+	'α': 'a', 'β': 'b', 'ϲ': 'c', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v', 'ο': 'o',
+	'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'γ': 'y', 'ζ': 'z',
+	// Armenian
+	'օ': 'o', 'ս': 'u', 'հ': 'h', 'ո': 'n', 'ց': 'g', 'զ': 'q',
+	// Latin lookalikes outside ASCII
+	'ɡ': 'g', 'ı': 'i', 'ɩ': 'i', 'ℓ': 'l', 'ʐ': 'z',
+}
+
+// skeleton folds a Unicode domain to the ASCII string it imitates.
+func skeleton(domain string) string {
+	var b strings.Builder
+	for _, r := range domain {
+		if l, ok := confusables[r]; ok {
+			r = l
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// mixedScript reports whether any label of a Unicode domain combines
+// letters from more than one script.
+func mixedScript(domain string) bool {
+	for _, label := range strings.Split(domain, ".") {
+		var script *unicode.RangeTable
+		for _, r := range label {
+			if !unicode.IsLetter(r) {
+				continue
+			}
+			s := scriptOf(r)
+			if script == nil {
+				script = s
+			} else if s != script {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+var scripts = []*unicode.RangeTable{
+	unicode.Latin, unicode.Cyrillic, unicode.Greek, unicode.Armenian,
+	unicode.Han, unicode.Arabic, unicode.Hebrew, unicode.Hangul,
+}
+
+func scriptOf(r rune) *unicode.RangeTable {
+	for _, s := range scripts {
+		if unicode.Is(s, r) {
+			return s
+		}
+	}
+	return nil
+}
+
+// homographTarget returns the known provider or corporate domain that a
+// Unicode domain imitates, or "".
+func (d *dataset) homographTarget(unicodeDomain string) string {
+	if isASCII(unicodeDomain) {
+		return ""
+	}
+	s := skeleton(unicodeDomain)
+	if s == unicodeDomain || !isASCII(s) {
+		return ""
+	}
+	if _, ok := d.corporate[s]; ok || d.personal[s] || d.free[s] {
+		return s
+	}
+	return ""
+}
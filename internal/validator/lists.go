@@ -0,0 +1,289 @@
+package validator
+
+import (
+	"sort"
+	"strings"
+)
+
+var (
+	// Known disposable email domains (simplified list)
+	defaultDisposableDomains = map[string]bool{
+		"mailinator.com":    true,
+		"guerrillamail.com": true,
+		"10minutemail.com":  true,
+		"tempmail.org":      true,
+		"yopmail.com":       true,
+		"mailnesia.com":     true,
+		"tempmailo.com":     true,
+		"throwaway.email":   true,
+		"trashmail.com":     true,
+		"mailcatch.com":     true,
+		"dispostable.com":   true,
+		"maildrop.cc":       true,
+		"fakeinbox.com":     true,
+		"mailforspam.com":   true,
+		"mintemail.com":     true,
+		"sharklasers.com":   true,
+		"spam4.me":          true,
+		"tempinbox.com":     true,
+		"trbvm.com":         true,
+	}
+
+	// Consumer mailbox providers that are always personal, including every
+	// brand domain of the built-in company graph
+	defaultPersonalDomains = map[string]bool{
+		"gmail.com":      true,
+		"googlemail.com": true,
+		"yahoo.com":      true,
+		"outlook.com":    true,
+		"hotmail.com":    true,
+		"live.com":       true,
+		"icloud.com":     true,
+		"me.com":         true,
+		"mac.com":        true,
+		"yandex.ru":      true,
+		"yandex.com":     true,
+		"ya.ru":          true,
+		"ya.com":         true,
+		"mail.ru":        true,
+		"protonmail.com": true,
+		"pm.me":          true,
+		"zoho.com":       true,
+	}
+)
+
+// dataset is an immutable snapshot of every domain list used for
+// classification. Validators swap whole snapshots instead of mutating maps
+// so readers never need a lock.
+type dataset struct {
+	lists map[ListKind][]domainList // every loaded source, in load order
+
+	// Effective lists: the union of all sources of each kind
+	corporate         map[string]string
+	disposable        map[string]bool
+	personal          map[string]bool
+	free              map[string]bool
+	overrideCorporate map[string]bool
+	overridePersonal  map[string]bool
+
+	roles      *roleMatcher
+	normalizer *normalizer
+	companies  map[string]*Company // employee and brand domain -> company
+	prints     *fingerprintDB
+	risk       map[string]int // reason code -> weight
+
+	// Host patterns of disposable services, beyond the disposable list
+	disposableMX []string
+	disposableNS []string
+	// MX hosts of disposable list domains -> the domain, see LearnDisposableMX
+	disposableHosts map[string]string
+}
+
+// domainList is one source of a list. Values are canonical corporate
+// domains for ListCorporate and empty otherwise.
+type domainList struct {
+	source  string
+	domains map[string]string
+}
+
+func newDataset(opts Options) *dataset {
+	d := &dataset{
+		lists:      make(map[ListKind][]domainList),
+		roles:      newRoleMatcher(opts.RoleAccounts),
+		normalizer: newNormalizer(opts.Normalization),
+		companies:  companyIndex(opts.Companies),
+		prints:     newFingerprintDB(opts.Fingerprints),
+		risk:       opts.RiskWeights,
+
+		disposableMX: lowerAll(opts.DisposableMX),
+		disposableNS: lowerAll(opts.DisposableNS),
+	}
+	companyDomains := make(map[string]string)
+	for _, c := range opts.Companies {
+		for _, domain := range c.EmployeeDomains {
+			companyDomains[strings.ToLower(domain)] = strings.ToLower(c.EmployeeDomains[0])
+		}
+	}
+	d.setList(ListCorporate, SourceCompanies, companyDomains)
+	d.setList(ListCorporate, SourceBuiltin, opts.CorporateDomains)
+	d.setList(ListDisposable, SourceBuiltin, listOf(opts.DisposableDomains))
+	d.setList(ListPersonal, SourceBuiltin, listOf(opts.PersonalDomains))
+	d.setList(ListFree, SourceBuiltin, listOf(opts.FreeProviders))
+	d.setList(ListCorporateOverride, SourceBuiltin, listOf(opts.CorporateOverrides))
+	d.setList(ListPersonalOverride, SourceBuiltin, listOf(opts.PersonalOverrides))
+	for _, l := range opts.Lists {
+		d.setList(l.Kind, l.Source, l.Domains)
+	}
+	return d
+}
+
+// clone returns a shallow copy; callers replace the maps they change.
+func (d *dataset) clone() *dataset {
+	c := *d
+	c.lists = make(map[ListKind][]domainList, len(d.lists))
+	for kind, sources := range d.lists {
+		c.lists[kind] = append([]domainList(nil), sources...)
+	}
+	return &c
+}
+
+// setList replaces the entries of source in the given list, adding the
+// source if it is new, and rebuilds the effective list.
+func (d *dataset) setList(kind ListKind, source string, domains map[string]string) {
+	l := domainList{source: source, domains: make(map[string]string, len(domains))}
+	for domain, canonical := range domains {
+		domain = strings.ToLower(strings.TrimSpace(domain))
+		if domain == "" {
+			continue
+		}
+		canonical = strings.ToLower(strings.TrimSpace(canonical))
+		if kind == ListCorporate && canonical == "" {
+			canonical = domain
+		}
+		l.domains[domain] = canonical
+	}
+
+	sources := d.lists[kind]
+	replaced := false
+	for i := range sources {
+		if sources[i].source == source {
+			sources[i], replaced = l, true
+			break
+		}
+	}
+	if !replaced {
+		sources = append(sources, l)
+	}
+	d.lists[kind] = sources
+	d.rebuild(kind)
+}
+
+func (d *dataset) rebuild(kind ListKind) {
+	if kind == ListCorporate {
+		d.corporate = make(map[string]string)
+		for _, l := range d.lists[kind] {
+			for domain, canonical := range l.domains {
+				d.corporate[domain] = canonical
+			}
+		}
+		return
+	}
+	set := make(map[string]bool)
+	for _, l := range d.lists[kind] {
+		for domain := range l.domains {
+			set[domain] = true
+		}
+	}
+	switch kind {
+	case ListDisposable:
+		d.disposable = set
+	case ListPersonal:
+		d.personal = set
+	case ListFree:
+		d.free = set
+	case ListCorporateOverride:
+		d.overrideCorporate = set
+	case ListPersonalOverride:
+		d.overridePersonal = set
+	}
+}
+
+// source returns the source that decides domain's entry in kind: the
+// first that lists it, or for the corporate map the last, since rebuild
+// lets later sources override the corporate domain.
+func (d *dataset) source(kind ListKind, domain string) string {
+	sources := d.lists[kind]
+	if kind == ListCorporate {
+		for i := len(sources) - 1; i >= 0; i-- {
+			if _, ok := sources[i].domains[domain]; ok {
+				return sources[i].source
+			}
+		}
+		return ""
+	}
+	for _, l := range sources {
+		if _, ok := l.domains[domain]; ok {
+			return l.source
+		}
+	}
+	return ""
+}
+
+// matchedSource returns the source of the list entry that produced rule.
+func (d *dataset) matchedSource(rule, domain string) string {
+	switch rule {
+	case RuleOverrideCorporate:
+		return d.source(ListCorporateOverride, domain)
+	case RuleOverridePersonal:
+		return d.source(ListPersonalOverride, domain)
+	case RuleDisposableList:
+		return d.source(ListDisposable, domain)
+	case RuleCorporateMap:
+		return d.source(ListCorporate, domain)
+	case RuleFreeList:
+		if s := d.source(ListFree, domain); s != "" {
+			return s
+		}
+		return d.source(ListPersonal, domain)
+	}
+	return ""
+}
+
+// classify applies the list-based part of the precedence chain:
+// override > disposable > corporate map > free list. It returns an empty
+// rule when none of the lists match and the MX heuristic has to decide.
+func (d *dataset) classify(domain string) (providerType string, rule string) {
+	switch {
+	case d.overrideCorporate[domain]:
+		return "corporate", RuleOverrideCorporate
+	case d.overridePersonal[domain]:
+		return "personal", RuleOverridePersonal
+	case d.disposable[domain]:
+		return "disposable", RuleDisposableList
+	}
+	if _, exists := d.corporate[domain]; exists {
+		return "corporate", RuleCorporateMap
+	}
+	if d.free[domain] || d.personal[domain] {
+		return "personal", RuleFreeList
+	}
+	return "unknown", ""
+}
+
+// classifyHost classifies the exact host first and falls back to its
+// registrable domain, so eng.google.com matches the google.com entry. It
+// also returns the list entry that matched.
+func (d *dataset) classifyHost(host, registrable string) (providerType, rule, matched string) {
+	providerType, rule = d.classify(host)
+	if rule != "" || registrable == "" || registrable == host {
+		return providerType, rule, host
+	}
+	if pt, r := d.classify(registrable); r != "" {
+		return pt, r, registrable
+	}
+	return providerType, rule, host
+}
+
+// known reports whether domain appears in any classification list.
+func (d *dataset) known(domain string) bool {
+	_, corporate := d.corporate[domain]
+	return corporate || d.personal[domain] || d.free[domain] || d.disposable[domain] ||
+		d.overrideCorporate[domain] || d.overridePersonal[domain]
+}
+
+func listOf(domains []string) map[string]string {
+	m := make(map[string]string, len(domains))
+	for _, d := range domains {
+		m[d] = ""
+	}
+	return m
+}
+
+func setKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
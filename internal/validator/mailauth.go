@@ -0,0 +1,390 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// spfLookupLimit is the RFC 7208 section 4.6.4 cap on DNS-querying terms
+// (include, a, mx, ptr, exists, redirect) while evaluating one record.
+const spfLookupLimit = 10
+
+var errMultipleRecords = errors.New("multiple records published")
+
+// DefaultDKIMSelectors are selectors used by the common mail platforms.
+// DKIM keys can only be found by guessing the selector, so a miss does not
+// mean the domain has no DKIM.
+var DefaultDKIMSelectors = []string{
+	"google", "selector1", "selector2", "k1", "k2", "default", "dkim", "mail",
+	"s1", "s2", "smtp", "zoho", "protonmail", "fm1", "mandrill", "everlytickey1",
+}
+
+type MailAuthOptions struct {
+	DKIMSelectors []string // defaults to DefaultDKIMSelectors
+}
+
+// MailAuth is the domain's published sender authentication posture.
+type MailAuth struct {
+	SPF   *SPFCheck   `json:"spf"`
+	DMARC *DMARCCheck `json:"dmarc"`
+	DKIM  *DKIMCheck  `json:"dkim"`
+}
+
+type SPFCheck struct {
+	Found    bool     `json:"found"`
+	Record   string   `json:"record,omitempty"`
+	All      string   `json:"all,omitempty"`      // qualified "all" term after redirects: -all, ~all, ?all or +all
+	Includes []string `json:"includes,omitempty"` // include: and redirect= domains in evaluation order
+	Lookups  int      `json:"dns_lookups"`
+	Error    string   `json:"error,omitempty"`
+}
+
+type DMARCCheck struct {
+	Found           bool     `json:"found"`
+	Record          string   `json:"record,omitempty"`
+	Domain          string   `json:"domain,omitempty"` // where the record was found, the organizational domain as a fallback
+	Policy          string   `json:"policy,omitempty"` // none, quarantine or reject
+	SubdomainPolicy string   `json:"subdomain_policy,omitempty"`
+	Percent         int      `json:"pct,omitempty"`
+	ReportURIs      []string `json:"rua,omitempty"`
+	Error           string   `json:"error,omitempty"`
+}
+
+type DKIMCheck struct {
+	Selectors []string `json:"selectors"` // probed selectors that publish a key
+	Checked   int      `json:"checked"`
+}
+
+// Valid reports whether a single DMARC record was found and parsed.
+// Receivers ignore a broken record, so it counts as none.
+func (d *DMARCCheck) Valid() bool {
+	return d != nil && d.Found && d.Error == ""
+}
+
+// Enforcing reports whether the DMARC policy asks receivers to act on
+// failures.
+func (d *DMARCCheck) Enforcing() bool {
+	return d.Valid() && (d.Policy == "quarantine" || d.Policy == "reject")
+}
+
+// Classification confidence per rule. The MX heuristic starts lower and is
+// adjusted by the domain's mail authentication records: real organisations
+// almost always publish SPF and DMARC, throwaway and parked domains rarely do.
+const (
+	confidenceOverride    = 1.0
+	confidenceList        = 0.95
+	confidenceHeuristic   = 0.6
+	confidenceNoMailAuth  = 0.3
+	confidenceHeuristicHi = 0.9
+)
+
+func classificationConfidence(rule string, auth *MailAuth) float64 {
+	switch rule {
+	case RuleOverrideCorporate, RuleOverridePersonal:
+		return confidenceOverride
+	case RuleDisposableList, RuleCorporateMap, RuleFreeList:
+		return confidenceList
+	case RuleMXHeuristic:
+	default:
+		return 0
+	}
+	if auth == nil {
+		return confidenceHeuristic
+	}
+	if !auth.SPF.Found && !auth.DMARC.Valid() {
+		return confidenceNoMailAuth
+	}
+	c := confidenceHeuristic
+	if auth.SPF.Found && auth.SPF.Error == "" {
+		c += 0.1
+	}
+	if auth.DMARC.Valid() {
+		c += 0.1
+	}
+	if auth.DMARC.Enforcing() {
+		c += 0.05
+	}
+	if len(auth.DKIM.Selectors) > 0 {
+		c += 0.05
+	}
+	if c > confidenceHeuristicHi {
+		c = confidenceHeuristicHi
+	}
+	return c
+}
+
+// checkMailAuth looks up SPF, DMARC and DKIM for domain. orgDomain is the
+// registrable domain used for the DMARC fallback.
+func checkMailAuth(ctx context.Context, r Resolver, domain, orgDomain string, opts *MailAuthOptions) *MailAuth {
+	selectors := opts.DKIMSelectors
+	if selectors == nil {
+		selectors = DefaultDKIMSelectors
+	}
+	auth := &MailAuth{}
+	var wg sync.WaitGroup
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		auth.SPF = checkSPF(ctx, r, domain)
+	}()
+	go func() {
+		defer wg.Done()
+		auth.DMARC = checkDMARC(ctx, r, domain, orgDomain)
+	}()
+	go func() {
+		defer wg.Done()
+		auth.DKIM = checkDKIM(ctx, r, domain, selectors)
+	}()
+	wg.Wait()
+	return auth
+}
+
+func checkSPF(ctx context.Context, r Resolver, domain string) *SPFCheck {
+	check := &SPFCheck{}
+	record, err := spfRecord(ctx, r, domain)
+	if err != nil {
+		check.Error = err.Error()
+		return check
+	}
+	if record == "" {
+		return check
+	}
+	check.Found = true
+	check.Record = record
+	visited := map[string]bool{domain: true}
+	if err := evalSPF(ctx, r, record, check, visited, true); err != "" {
+		check.Error = err
+	}
+	return check
+}
+
+// evalSPF walks one record, following include: and redirect= chains.
+// top is set for the queried domain's own record (or its redirect target),
+// whose "all" term decides the domain's policy.
+func evalSPF(ctx context.Context, r Resolver, record string, check *SPFCheck, visited map[string]bool, top bool) string {
+	redirect := ""
+	hasAll := false
+	for _, term := range strings.Fields(record)[1:] {
+		term = strings.ToLower(term)
+		if name, value, ok := strings.Cut(term, "="); ok {
+			if name == "redirect" {
+				redirect = value
+			}
+			continue
+		}
+		qualifier := "+"
+		if strings.ContainsAny(term[:1], "+-~?") {
+			qualifier, term = term[:1], term[1:]
+		}
+		mechanism, arg, _ := strings.Cut(term, ":")
+		if i := strings.IndexByte(mechanism, '/'); i >= 0 {
+			mechanism = mechanism[:i]
+		}
+		switch mechanism {
+		case "all":
+			hasAll = true
+			if top {
+				check.All = qualifier + "all"
+			}
+		case "a", "mx", "ptr", "exists":
+			check.Lookups++
+		case "include":
+			check.Lookups++
+			if check.Lookups > spfLookupLimit {
+				return "too many DNS lookups"
+			}
+			check.Includes = append(check.Includes, arg)
+			if visited[arg] {
+				return "include loop at " + arg
+			}
+			visited[arg] = true
+			sub, err := spfRecord(ctx, r, arg)
+			if err != nil {
+				return "include " + arg + ": " + err.Error()
+			}
+			if sub == "" {
+				return "include " + arg + " has no SPF record"
+			}
+			if e := evalSPF(ctx, r, sub, check, visited, false); e != "" {
+				return e
+			}
+		}
+		if check.Lookups > spfLookupLimit {
+			return "too many DNS lookups"
+		}
+	}
+
+	// A redirect only applies when the record has no "all" term
+	if redirect == "" || hasAll {
+		return ""
+	}
+	check.Lookups++
+	if check.Lookups > spfLookupLimit {
+		return "too many DNS lookups"
+	}
+	check.Includes = append(check.Includes, redirect)
+	if visited[redirect] {
+		return "redirect loop at " + redirect
+	}
+	visited[redirect] = true
+	sub, err := spfRecord(ctx, r, redirect)
+	if err != nil {
+		return "redirect " + redirect + ": " + err.Error()
+	}
+	if sub == "" {
+		return "redirect " + redirect + " has no SPF record"
+	}
+	return evalSPF(ctx, r, sub, check, visited, top)
+}
+
+// spfRecord returns the domain's single SPF record, "" if there is none.
+func spfRecord(ctx context.Context, r Resolver, domain string) (string, error) {
+	txts, err := lookupTXT(ctx, r, domain)
+	if err != nil {
+		return "", err
+	}
+	var records []string
+	for _, txt := range txts {
+		if lower := strings.ToLower(txt); lower == "v=spf1" || strings.HasPrefix(lower, "v=spf1 ") {
+			records = append(records, txt)
+		}
+	}
+	switch len(records) {
+	case 0:
+		return "", nil
+	case 1:
+		return records[0], nil
+	default:
+		return "", errMultipleRecords
+	}
+}
+
+func checkDMARC(ctx context.Context, r Resolver, domain, orgDomain string) *DMARCCheck {
+	check := &DMARCCheck{}
+	candidates := []string{domain}
+	if orgDomain != "" && orgDomain != domain {
+		candidates = append(candidates, orgDomain)
+	}
+	for _, d := range candidates {
+		txts, err := lookupTXT(ctx, r, "_dmarc."+d)
+		if err != nil {
+			check.Error = err.Error()
+			return check
+		}
+		var records []string
+		for _, txt := range txts {
+			if strings.HasPrefix(strings.ToLower(strings.TrimSpace(txt)), "v=dmarc1") {
+				records = append(records, txt)
+			}
+		}
+		if len(records) == 0 {
+			continue
+		}
+		check.Found = true
+		check.Domain = d
+		if len(records) > 1 {
+			check.Error = errMultipleRecords.Error()
+			return check
+		}
+		parseDMARC(records[0], check)
+		return check
+	}
+	return check
+}
+
+func parseDMARC(record string, check *DMARCCheck) {
+	check.Record = record
+	check.Percent = 100
+	for _, tag := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(tag), "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "p":
+			check.Policy = strings.ToLower(value)
+		case "sp":
+			check.SubdomainPolicy = strings.ToLower(value)
+		case "pct":
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 && n <= 100 {
+				check.Percent = n
+			}
+		case "rua":
+			for _, uri := range strings.Split(value, ",") {
+				if uri = strings.TrimSpace(uri); uri != "" {
+					check.ReportURIs = append(check.ReportURIs, uri)
+				}
+			}
+		}
+	}
+	switch check.Policy {
+	case "none", "quarantine", "reject":
+	case "":
+		check.Error = "missing p= tag"
+	default:
+		check.Error = "invalid policy " + check.Policy
+	}
+}
+
+func checkDKIM(ctx context.Context, r Resolver, domain string, selectors []string) *DKIMCheck {
+	check := &DKIMCheck{Selectors: []string{}, Checked: len(selectors)}
+	found := make([]bool, len(selectors))
+	var wg sync.WaitGroup
+	for i, sel := range selectors {
+		wg.Add(1)
+		go func(i int, sel string) {
+			defer wg.Done()
+			txts, err := lookupTXT(ctx, r, sel+"._domainkey."+domain)
+			if err != nil {
+				return
+			}
+			for _, txt := range txts {
+				if hasDKIMKey(txt) {
+					found[i] = true
+					return
+				}
+			}
+		}(i, sel)
+	}
+	wg.Wait()
+	for i, ok := range found {
+		if ok {
+			check.Selectors = append(check.Selectors, selectors[i])
+		}
+	}
+	return check
+}
+
+// hasDKIMKey reports whether a DKIM key record has a non-empty p= tag; an
+// empty key means the selector was revoked.
+func hasDKIMKey(record string) bool {
+	for _, tag := range strings.Split(record, ";") {
+		name, value, ok := strings.Cut(strings.TrimSpace(tag), "=")
+		if ok && strings.TrimSpace(name) == "p" {
+			return strings.TrimSpace(value) != ""
+		}
+	}
+	return false
+}
+
+// lookupTXT treats a missing name as an empty answer.
+func lookupTXT(ctx context.Context, r Resolver, name string) ([]string, error) {
+	txts, err := r.LookupTXT(ctx, name)
+	if err != nil {
+		if isNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return txts, nil
+}
+
+func isNotFound(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
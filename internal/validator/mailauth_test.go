@@ -0,0 +1,122 @@
+package validator
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"slices"
+	"testing"
+)
+
+func TestCheckSPF(t *testing.T) {
+	r := NewFakeResolver().
+		AddTXT("acme.example", "google-site-verification=abc", "v=spf1 include:_spf.acme.example include:mail.vendor.example ~all").
+		AddTXT("_spf.acme.example", "v=spf1 ip4:192.0.2.0/24 include:_spf2.acme.example -all").
+		AddTXT("_spf2.acme.example", "v=spf1 a mx -all").
+		AddTXT("mail.vendor.example", "v=spf1 ip4:198.51.100.1 ?all").
+		AddTXT("redirect.example", "v=spf1 redirect=acme.example").
+		AddTXT("twice.example", "v=spf1 -all", "v=spf1 ~all").
+		AddTXT("loop.example", "v=spf1 include:loop.example -all").
+		AddTXT("broken.example", "v=spf1 include:missing.example -all")
+	// A chain of 11 includes exceeds the lookup limit
+	for i := 0; i < 11; i++ {
+		r.AddTXT(fmt.Sprintf("deep%d.example", i), fmt.Sprintf("v=spf1 include:deep%d.example -all", i+1))
+	}
+	r.AddTXT("deep11.example", "v=spf1 -all")
+
+	tests := []struct {
+		domain   string
+		all      string
+		includes []string
+		lookups  int
+		err      string
+	}{
+		{"acme.example", "~all", []string{"_spf.acme.example", "_spf2.acme.example", "mail.vendor.example"}, 5, ""},
+		{"redirect.example", "~all", []string{"acme.example", "_spf.acme.example", "_spf2.acme.example", "mail.vendor.example"}, 6, ""},
+		{"twice.example", "", nil, 0, errMultipleRecords.Error()},
+		{"loop.example", "", []string{"loop.example"}, 1, "include loop at loop.example"},
+		{"broken.example", "", []string{"missing.example"}, 1, "include missing.example has no SPF record"},
+		{"deep0.example", "", nil, 11, "too many DNS lookups"},
+	}
+	for _, tt := range tests {
+		got := checkSPF(context.Background(), r, tt.domain)
+		if got.All != tt.all || got.Lookups != tt.lookups || got.Error != tt.err {
+			t.Errorf("%s: all %q lookups %d error %q, want %q %d %q", tt.domain, got.All, got.Lookups, got.Error, tt.all, tt.lookups, tt.err)
+		}
+		if tt.includes != nil && !reflect.DeepEqual(got.Includes, tt.includes) {
+			t.Errorf("%s: includes %v, want %v", tt.domain, got.Includes, tt.includes)
+		}
+	}
+}
+
+func TestCheckDMARCAndDKIM(t *testing.T) {
+	r := NewFakeResolver().
+		AddTXT("_dmarc.acme.example", "v=DMARC1; p=reject; sp=quarantine; pct=50; rua=mailto:a@acme.example,mailto:b@acme.example").
+		AddTXT("selector1._domainkey.acme.example", "v=DKIM1; k=rsa; p=MIGfMA0GCSqGSIb3DQEBAQUAA4GNADCBiQKBgQC").
+		AddTXT("s1._domainkey.acme.example", "v=DKIM1; p=")
+
+	d := checkDMARC(context.Background(), r, "eu.acme.example", "acme.example")
+	want := &DMARCCheck{
+		Found:           true,
+		Record:          "v=DMARC1; p=reject; sp=quarantine; pct=50; rua=mailto:a@acme.example,mailto:b@acme.example",
+		Domain:          "acme.example",
+		Policy:          "reject",
+		SubdomainPolicy: "quarantine",
+		Percent:         50,
+		ReportURIs:      []string{"mailto:a@acme.example", "mailto:b@acme.example"},
+	}
+	if !reflect.DeepEqual(d, want) || !d.Enforcing() {
+		t.Errorf("dmarc = %+v", d)
+	}
+	if d := checkDMARC(context.Background(), r, "other.example", "other.example"); d.Found {
+		t.Errorf("other.example: dmarc = %+v", d)
+	}
+
+	k := checkDKIM(context.Background(), r, "acme.example", DefaultDKIMSelectors)
+	if !reflect.DeepEqual(k.Selectors, []string{"selector1"}) || k.Checked != len(DefaultDKIMSelectors) {
+		t.Errorf("dkim = %+v", k)
+	}
+}
+
+func TestMailAuthConfidence(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MailAuth = &MailAuthOptions{DKIMSelectors: []string{"google"}}
+	opts.Resolver = NewFakeResolver().
+		AddMX("acme.example", "mx.acme.example.", 10).
+		AddTXT("acme.example", "v=spf1 -all").
+		AddTXT("_dmarc.acme.example", "v=DMARC1; p=quarantine").
+		AddTXT("google._domainkey.acme.example", "v=DKIM1; p=abc").
+		AddMX("parked.example", "mx.parked.example.", 10).
+		AddMX("broken.example", "mx.broken.example.", 10).
+		AddTXT("_dmarc.broken.example", "v=DMARC1; rua=mailto:d@broken.example").
+		AddHost("mx.acme.example", "64.233.184.26").
+		AddHost("mx.parked.example", "64.233.184.27").
+		AddHost("mx.broken.example", "64.233.184.28")
+	v := New(opts)
+
+	res := v.Validate("a@acme.example")
+	if res.MailAuth == nil || !res.MailAuth.SPF.Found || res.MailAuth.DMARC.Policy != "quarantine" {
+		t.Fatalf("acme.example: mail_auth = %+v", res.MailAuth)
+	}
+	if res.Confidence != confidenceHeuristicHi {
+		t.Errorf("acme.example: confidence %v, want %v", res.Confidence, confidenceHeuristicHi)
+	}
+	if res := v.Validate("a@parked.example"); res.Confidence != confidenceNoMailAuth {
+		t.Errorf("parked.example: confidence %v, want %v", res.Confidence, confidenceNoMailAuth)
+	}
+	if res := v.Validate("a@yopmail.com"); res.Confidence != confidenceList {
+		t.Errorf("yopmail.com: confidence %v, want %v", res.Confidence, confidenceList)
+	}
+
+	// A DMARC record that does not parse counts as missing
+	res = v.Validate("a@broken.example")
+	if !res.MailAuth.DMARC.Found || res.MailAuth.DMARC.Error == "" {
+		t.Fatalf("broken.example: dmarc = %+v", res.MailAuth.DMARC)
+	}
+	if res.Confidence != confidenceNoMailAuth {
+		t.Errorf("broken.example: confidence %v, want %v", res.Confidence, confidenceNoMailAuth)
+	}
+	if !slices.Contains(res.Reasons, ReasonNoDMARC) {
+		t.Errorf("broken.example: reasons %v, want %s", res.Reasons, ReasonNoDMARC)
+	}
+}
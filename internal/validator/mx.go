@@ -0,0 +1,193 @@
+package validator
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MX target statuses.
+const (
+	MXOK       = "ok"
+	MXNull     = "null"     // "." target, RFC 7505
+	MXDangling = "dangling" // target has no A/AAAA records
+	MXLoopback = "loopback"
+	MXPrivate  = "private"  // RFC 1918, RFC 4193 and link-local addresses
+	MXReserved = "reserved" // unspecified, CGNAT, documentation, benchmarking, multicast and class E ranges
+	MXUnknown  = "unknown"  // the target lookup failed without a definitive answer
+)
+
+// MXHost is the diagnosis of one MX record.
+type MXHost struct {
+	Host      string   `json:"host"`
+	Pref      uint16   `json:"pref"`
+	Addresses []string `json:"addresses,omitempty"`
+	Status    string   `json:"status"` // see MX* constants
+	Error     string   `json:"error,omitempty"`
+}
+
+// reservedPrefixes are special-purpose ranges that netip has no predicate
+// for. Nothing routable on the public internet lives there.
+var reservedPrefixes = []netip.Prefix{
+	netip.MustParsePrefix("0.0.0.0/8"),
+	netip.MustParsePrefix("100.64.0.0/10"),
+	netip.MustParsePrefix("192.0.0.0/24"),
+	netip.MustParsePrefix("192.0.2.0/24"),
+	netip.MustParsePrefix("198.18.0.0/15"),
+	netip.MustParsePrefix("198.51.100.0/24"),
+	netip.MustParsePrefix("203.0.113.0/24"),
+	netip.MustParsePrefix("240.0.0.0/4"),
+	netip.MustParsePrefix("100::/64"),
+	netip.MustParsePrefix("2001:db8::/32"),
+}
+
+// isNullMX reports whether the domain publishes a null MX: a single "."
+// record, which states that it accepts no mail at all.
+func isNullMX(hosts []MXHost) bool {
+	return len(hosts) == 1 && hosts[0].Status == MXNull
+}
+
+// diagnoseMX resolves every MX target concurrently and classifies its
+// addresses. The result is sorted by preference.
+func diagnoseMX(ctx context.Context, r Resolver, records []*net.MX) []MXHost {
+	hosts := make([]MXHost, len(records))
+	var wg sync.WaitGroup
+	for i, mx := range records {
+		hosts[i] = MXHost{Host: mxTarget(mx), Pref: mx.Pref}
+		if hosts[i].Host == "" {
+			hosts[i].Status = MXNull
+			continue
+		}
+		wg.Add(1)
+		go func(h *MXHost) {
+			defer wg.Done()
+			diagnoseMXHost(ctx, r, h)
+		}(&hosts[i])
+	}
+	wg.Wait()
+	sort.SliceStable(hosts, func(i, j int) bool { return hosts[i].Pref < hosts[j].Pref })
+	return hosts
+}
+
+func diagnoseMXHost(ctx context.Context, r Resolver, h *MXHost) {
+	// MX targets must be host names, but IP literals are common enough in
+	// misconfigured zones that they are checked rather than looked up
+	if _, err := netip.ParseAddr(h.Host); err == nil {
+		h.Addresses = []string{h.Host}
+	} else {
+		addrs, err := r.LookupHost(ctx, h.Host)
+		switch {
+		case err != nil && isNotFound(err):
+			h.Status = MXDangling
+			return
+		case err != nil:
+			h.Status = MXUnknown
+			h.Error = err.Error()
+			return
+		case len(addrs) == 0:
+			h.Status = MXDangling
+			return
+		}
+		h.Addresses = addrs
+	}
+
+	h.Status = MXOK
+	for _, a := range h.Addresses {
+		if status := addressStatus(a); statusRank(status) > statusRank(h.Status) {
+			h.Status = status
+		}
+	}
+}
+
+// addressStatus classifies one target address.
+func addressStatus(s string) string {
+	addr, err := netip.ParseAddr(s)
+	if err != nil {
+		return MXUnknown
+	}
+	addr = addr.Unmap()
+	switch {
+	case addr.IsLoopback():
+		return MXLoopback
+	case addr.IsPrivate(), addr.IsLinkLocalUnicast():
+		return MXPrivate
+	case addr.IsUnspecified(), addr.IsMulticast(), addr.IsInterfaceLocalMulticast():
+		return MXReserved
+	}
+	for _, p := range reservedPrefixes {
+		if p.Contains(addr) {
+			return MXReserved
+		}
+	}
+	return MXOK
+}
+
+// statusRank orders address statuses so that a host reports the worst of
+// its addresses.
+func statusRank(status string) int {
+	switch status {
+	case MXLoopback:
+		return 4
+	case MXPrivate:
+		return 3
+	case MXReserved:
+		return 2
+	case MXUnknown:
+		return 1
+	}
+	return 0
+}
+
+// usableMX returns the records whose targets may receive mail. Targets that
+// could not be checked are given the benefit of the doubt; private,
+// loopback and reserved targets only count when allowPrivate is set.
+func usableMX(records []*net.MX, hosts []MXHost, allowPrivate bool) []*net.MX {
+	status := make(map[string]string, len(hosts))
+	for _, h := range hosts {
+		status[h.Host] = h.Status
+	}
+	var usable []*net.MX
+	for _, mx := range records {
+		switch status[mxTarget(mx)] {
+		case MXOK, MXUnknown:
+			usable = append(usable, mx)
+		case MXLoopback, MXPrivate, MXReserved:
+			if allowPrivate {
+				usable = append(usable, mx)
+			}
+		}
+	}
+	return usable
+}
+
+// probeableMX returns the hosts the SMTP probe may connect to. Unlike
+// usableMX it gives no benefit of the doubt: a host whose addresses could
+// not be checked is only probed when allowPrivate is set.
+func probeableMX(hosts []MXHost, allowPrivate bool) []MXHost {
+	var probeable []MXHost
+	for _, h := range hosts {
+		switch h.Status {
+		case MXOK:
+			probeable = append(probeable, h)
+		case MXLoopback, MXPrivate, MXReserved, MXUnknown:
+			if allowPrivate {
+				probeable = append(probeable, h)
+			}
+		}
+	}
+	return probeable
+}
+
+// sortedMX returns the records in preference order.
+func sortedMX(records []*net.MX) []*net.MX {
+	sorted := append([]*net.MX(nil), records...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pref < sorted[j].Pref })
+	return sorted
+}
+
+func mxTarget(mx *net.MX) string {
+	return strings.ToLower(strings.TrimSuffix(mx.Host, "."))
+}
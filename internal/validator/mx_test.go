@@ -0,0 +1,92 @@
+package validator
+
+import "testing"
+
+func TestAddressStatus(t *testing.T) {
+	tests := []struct {
+		addr string
+		want string
+	}{
+		{"142.250.27.26", MXOK},
+		{"2a00:1450:400c:c0b::1a", MXOK},
+		{"127.0.0.1", MXLoopback},
+		{"::1", MXLoopback},
+		{"::ffff:127.0.0.1", MXLoopback},
+		{"10.1.2.3", MXPrivate},
+		{"172.16.0.1", MXPrivate},
+		{"192.168.1.1", MXPrivate},
+		{"fd00::1", MXPrivate},
+		{"169.254.1.1", MXPrivate},
+		{"0.0.0.0", MXReserved},
+		{"100.64.0.1", MXReserved},
+		{"192.0.2.1", MXReserved},
+		{"240.0.0.1", MXReserved},
+		{"2001:db8::25", MXReserved},
+	}
+	for _, tt := range tests {
+		if got := addressStatus(tt.addr); got != tt.want {
+			t.Errorf("addressStatus(%s) = %q, want %q", tt.addr, got, tt.want)
+		}
+	}
+}
+
+func TestMXDiagnostics(t *testing.T) {
+	res := NewFakeResolver().
+		AddMX("nullmx.example", ".", 0).
+		AddMX("local.example", "mx.local.example.", 10).
+		AddMX("lan.example", "mx1.lan.example.", 10).
+		AddMX("lan.example", "MX2.lan.example.", 20).
+		AddMX("dangling.example", "mx.gone.example.", 10).
+		AddMX("literal.example", "10.0.0.25.", 10).
+		AddMX("backup.example", "mx2.backup.example.", 20).
+		AddMX("backup.example", "mx1.backup.example.", 10).
+		AddHost("mx.local.example", "127.0.0.1").
+		AddHost("mx1.lan.example", "192.168.1.25").
+		AddHost("mx2.lan.example", "64.233.184.26", "10.0.0.25").
+		AddHost("mx2.backup.example", "64.233.184.27")
+
+	opts := DefaultOptions()
+	opts.Resolver = res
+	v := New(opts)
+
+	tests := []struct {
+		email       string
+		domainValid bool
+		nullMX      bool
+		statuses    []string
+	}{
+		{"a@nullmx.example", false, true, []string{MXNull}},
+		{"a@local.example", false, false, []string{MXLoopback}},
+		{"a@lan.example", false, false, []string{MXPrivate, MXPrivate}},
+		{"a@dangling.example", false, false, []string{MXDangling}},
+		{"a@literal.example", false, false, []string{MXPrivate}},
+		{"a@backup.example", true, false, []string{MXDangling, MXOK}},
+	}
+	for _, tt := range tests {
+		r := v.Validate(tt.email)
+		if r.DomainValid != tt.domainValid || r.NullMX != tt.nullMX || !r.MXRecordsFound {
+			t.Errorf("%s: domain_valid=%v null_mx=%v mx_records_found=%v, want %v %v true",
+				tt.email, r.DomainValid, r.NullMX, r.MXRecordsFound, tt.domainValid, tt.nullMX)
+		}
+		if len(r.MXHosts) != len(tt.statuses) {
+			t.Fatalf("%s: mx_hosts = %+v", tt.email, r.MXHosts)
+		}
+		for i, h := range r.MXHosts {
+			if h.Status != tt.statuses[i] {
+				t.Errorf("%s: mx_hosts[%d] = %+v, want status %q", tt.email, i, h, tt.statuses[i])
+			}
+		}
+		if !tt.domainValid && (r.Valid || r.IsCorporate) {
+			t.Errorf("%s: valid=%v corporate=%v for a domain that cannot receive mail", tt.email, r.Valid, r.IsCorporate)
+		}
+	}
+
+	// Intranet deployments may accept internal mail hosts
+	opts.AllowPrivateMX = true
+	if r := New(opts).Validate("a@lan.example"); !r.DomainValid {
+		t.Errorf("lan.example with AllowPrivateMX: domain_valid=false, %s", r.Message)
+	}
+	if r := New(opts).Validate("a@nullmx.example"); r.DomainValid {
+		t.Error("null MX accepted with AllowPrivateMX")
+	}
+}
@@ -0,0 +1,116 @@
+package validator
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+// NormalizationRule describes which spellings of an address a provider
+// delivers to the same mailbox.
+type NormalizationRule struct {
+	// Domains served by the same mailbox namespace; the first is canonical.
+	Domains []string `json:"domains"`
+	// TagSeparator starts a sub-address tag ("+" for john+promo@), or is
+	// empty when the provider does not support tags.
+	TagSeparator string `json:"tag_separator,omitempty"`
+	// IgnoreDots is set when dots in the local part are not significant.
+	IgnoreDots bool `json:"ignore_dots,omitempty"`
+}
+
+//go:embed data/normalization_rules.json
+var normalizationRulesJSON []byte
+
+// defaultNormalizationRules covers the large consumer providers. Local parts
+// of these providers are case-insensitive; domains without a rule keep their
+// local part as written.
+var defaultNormalizationRules = func() []NormalizationRule {
+	rules, err := LoadNormalizationRules(bytes.NewReader(normalizationRulesJSON))
+	if err != nil {
+		panic(err)
+	}
+	return rules
+}()
+
+// LoadNormalizationRules decodes a JSON array of normalization rules. Every
+// rule needs at least one domain, and a tag separator is a single
+// character.
+func LoadNormalizationRules(r io.Reader) ([]NormalizationRule, error) {
+	var rules []NormalizationRule
+	if err := json.NewDecoder(r).Decode(&rules); err != nil {
+		return nil, fmt.Errorf("failed to decode normalization rules: %w", err)
+	}
+	for i, rule := range rules {
+		if len(rule.Domains) == 0 {
+			return nil, fmt.Errorf("normalization rule %d: domains are required", i)
+		}
+		for _, d := range rule.Domains {
+			if strings.TrimSpace(d) == "" {
+				return nil, fmt.Errorf("normalization rule %d: empty domain", i)
+			}
+		}
+		if utf8.RuneCountInString(rule.TagSeparator) > 1 {
+			return nil, fmt.Errorf("normalization rule %d: tag separator %q is longer than one character", i, rule.TagSeparator)
+		}
+	}
+	return rules, nil
+}
+
+// DefaultNormalizationRules returns a copy of the built-in provider rules.
+func DefaultNormalizationRules() []NormalizationRule {
+	rules := make([]NormalizationRule, len(defaultNormalizationRules))
+	for i, r := range defaultNormalizationRules {
+		r.Domains = append([]string(nil), r.Domains...)
+		rules[i] = r
+	}
+	return rules
+}
+
+// normalizer indexes normalization rules by domain.
+type normalizer struct {
+	rules map[string]*normalizerEntry
+}
+
+type normalizerEntry struct {
+	canonical string
+	rule      NormalizationRule
+}
+
+func newNormalizer(rules []NormalizationRule) *normalizer {
+	n := &normalizer{rules: make(map[string]*normalizerEntry)}
+	for _, r := range rules {
+		if len(r.Domains) == 0 {
+			continue
+		}
+		e := &normalizerEntry{canonical: strings.ToLower(strings.TrimSpace(r.Domains[0])), rule: r}
+		for _, d := range r.Domains {
+			if d = strings.ToLower(strings.TrimSpace(d)); d != "" {
+				n.rules[d] = e
+			}
+		}
+	}
+	return n
+}
+
+// canonical returns the address a provider actually delivers local@domain
+// to. domain must already be lower-case.
+func (n *normalizer) canonical(local, domain string) string {
+	e, ok := n.rules[domain]
+	if !ok || strings.HasPrefix(local, `"`) {
+		return local + "@" + domain
+	}
+	local = strings.ToLower(local)
+	if sep := e.rule.TagSeparator; sep != "" {
+		if i := strings.Index(local, sep); i > 0 {
+			local = local[:i]
+		}
+	}
+	if e.rule.IgnoreDots {
+		local = strings.ReplaceAll(local, ".", "")
+	}
+	return local + "@" + e.canonical
+}
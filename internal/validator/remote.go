@@ -0,0 +1,329 @@
+package validator
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	_ "embed"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+//go:embed data/free_providers.json
+var freeProvidersFallback []byte
+
+// FreeProvidersFallback returns the free provider list compiled into the
+// binary, used when neither the remote list nor a snapshot is available.
+// It is a minimal subset of the upstream list covering the most common
+// providers, so a list served from it is reported as degraded.
+func FreeProvidersFallback() []byte {
+	return append([]byte(nil), freeProvidersFallback...)
+}
+
+// Origins of the data a RemoteList currently serves.
+const (
+	OriginRemote   = "remote"
+	OriginSnapshot = "snapshot"
+	OriginEmbedded = "embedded"
+)
+
+type RemoteListOptions struct {
+	Kind         ListKind
+	URL          string
+	Interval     time.Duration // time between successful refreshes, defaults to 24h
+	MinBackoff   time.Duration // first retry delay after a failure, defaults to 30s
+	MaxBackoff   time.Duration // defaults to Interval
+	SnapshotPath string        // last-known-good copy on disk, "" disables it
+	Fallback     []byte        // embedded copy in the URL's format, may be nil
+	MaxBytes     int64         // larger downloads are rejected, defaults to 16 MiB
+	Client       *http.Client
+}
+
+// RemoteListStatus is reported by the health endpoint.
+type RemoteListStatus struct {
+	Kind       ListKind   `json:"list"`
+	URL        string     `json:"url"`
+	Origin     string     `json:"origin,omitempty"` // remote, snapshot or embedded; empty until loaded
+	Degraded   bool       `json:"degraded"`         // serving the embedded subset or nothing
+	Version    string     `json:"version,omitempty"`
+	Entries    int        `json:"entries"`
+	FetchedAt  *time.Time `json:"fetched_at,omitempty"` // when the served data was downloaded
+	AgeSeconds int64      `json:"age_seconds,omitempty"`
+	CheckedAt  *time.Time `json:"checked_at,omitempty"` // last successful request, including 304s
+	LastError  string     `json:"last_error,omitempty"`
+	Failures   int        `json:"consecutive_failures"`
+}
+
+// RemoteList keeps one validator list in sync with a URL. It revalidates
+// with ETag / If-Modified-Since, retries failures with exponential backoff
+// and saves every successful download as a snapshot that is loaded on the
+// next start before the first fetch.
+type RemoteList struct {
+	v    *Validator
+	opts RemoteListOptions
+
+	mu       sync.Mutex
+	snap     listSnapshot
+	status   RemoteListStatus
+	checked  time.Time
+	failures int
+}
+
+// listSnapshot is the on-disk last-known-good copy.
+type listSnapshot struct {
+	URL          string            `json:"url"`
+	ETag         string            `json:"etag,omitempty"`
+	LastModified string            `json:"last_modified,omitempty"`
+	Version      string            `json:"version"`
+	FetchedAt    time.Time         `json:"fetched_at"`
+	Domains      map[string]string `json:"domains"`
+}
+
+func NewRemoteList(v *Validator, opts RemoteListOptions) *RemoteList {
+	if opts.Interval <= 0 {
+		opts.Interval = 24 * time.Hour
+	}
+	if opts.MinBackoff <= 0 {
+		opts.MinBackoff = 30 * time.Second
+	}
+	if opts.MaxBackoff <= 0 {
+		opts.MaxBackoff = opts.Interval
+	}
+	if opts.MaxBytes <= 0 {
+		opts.MaxBytes = 16 << 20
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &RemoteList{
+		v:      v,
+		opts:   opts,
+		status: RemoteListStatus{Kind: opts.Kind, URL: opts.URL},
+	}
+}
+
+// Bootstrap loads the snapshot, or the embedded fallback when there is no
+// usable snapshot, so the list is populated before the first fetch.
+func (r *RemoteList) Bootstrap() error {
+	if r.opts.SnapshotPath != "" {
+		snap, err := r.readSnapshot()
+		if err == nil {
+			r.apply(snap, OriginSnapshot)
+			return nil
+		}
+		if !os.IsNotExist(err) {
+			log.Printf("lists: ignoring snapshot %s: %v", r.opts.SnapshotPath, err)
+		}
+	}
+	if r.opts.Fallback == nil {
+		return nil
+	}
+	domains, err := ReadDomainList(bytes.NewReader(r.opts.Fallback), r.format())
+	if err != nil {
+		return fmt.Errorf("failed to decode embedded %s list: %w", r.opts.Kind, err)
+	}
+	r.apply(listSnapshot{URL: r.opts.URL, Version: "embedded-" + digest(r.opts.Fallback), Domains: domains}, OriginEmbedded)
+	return nil
+}
+
+// Refresh fetches the list once. A 304 answer keeps the current data.
+func (r *RemoteList) Refresh(ctx context.Context) error {
+	r.mu.Lock()
+	etag, lastModified := r.snap.ETag, r.snap.LastModified
+	r.mu.Unlock()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, r.opts.URL, nil)
+	if err != nil {
+		return err
+	}
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	}
+	resp, err := r.opts.Client.Do(req)
+	if err != nil {
+		return r.fail(fmt.Errorf("failed to fetch %s list: %w", r.opts.Kind, err))
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusNotModified:
+		r.succeed()
+		return nil
+	case resp.StatusCode != http.StatusOK:
+		return r.fail(fmt.Errorf("failed to fetch %s list: %s", r.opts.Kind, resp.Status))
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, r.opts.MaxBytes+1))
+	if err != nil {
+		return r.fail(fmt.Errorf("failed to fetch %s list: %w", r.opts.Kind, err))
+	}
+	if int64(len(body)) > r.opts.MaxBytes {
+		return r.fail(fmt.Errorf("refusing %s list larger than %d bytes", r.opts.Kind, r.opts.MaxBytes))
+	}
+	domains, err := ReadDomainList(bytes.NewReader(body), r.format())
+	if err != nil {
+		return r.fail(fmt.Errorf("failed to decode %s list: %w", r.opts.Kind, err))
+	}
+	if len(domains) == 0 {
+		// An empty list is far more likely a publishing mistake than intent
+		return r.fail(fmt.Errorf("refusing empty %s list", r.opts.Kind))
+	}
+
+	snap := listSnapshot{
+		URL:          r.opts.URL,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		Version:      resp.Header.Get("ETag"),
+		FetchedAt:    time.Now().UTC(),
+		Domains:      domains,
+	}
+	if snap.Version == "" {
+		snap.Version = digest(body)
+	}
+	r.apply(snap, OriginRemote)
+	r.succeed()
+	if r.opts.SnapshotPath != "" {
+		if err := r.writeSnapshot(snap); err != nil {
+			log.Printf("lists: %v", err)
+		}
+	}
+	return nil
+}
+
+// Run refreshes the list until ctx is done: every Interval after a
+// success, with exponential backoff after failures.
+func (r *RemoteList) Run(ctx context.Context) {
+	for {
+		wait := r.opts.Interval
+		if err := r.Refresh(ctx); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			r.mu.Lock()
+			wait = r.backoff(r.failures)
+			r.mu.Unlock()
+			log.Printf("lists: %v (retrying in %s)", err, wait)
+		}
+		t := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			t.Stop()
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func (r *RemoteList) Status() RemoteListStatus {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s := r.status
+	s.Failures = r.failures
+	s.Degraded = s.Origin == "" || s.Origin == OriginEmbedded
+	if !r.snap.FetchedAt.IsZero() {
+		fetched := r.snap.FetchedAt
+		s.FetchedAt = &fetched
+		s.AgeSeconds = int64(time.Since(fetched) / time.Second)
+	}
+	if !r.checked.IsZero() {
+		checked := r.checked
+		s.CheckedAt = &checked
+	}
+	return s
+}
+
+// backoff returns the delay before retry number n (1-based).
+func (r *RemoteList) backoff(n int) time.Duration {
+	d := r.opts.MinBackoff
+	for i := 1; i < n && d < r.opts.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > r.opts.MaxBackoff {
+		d = r.opts.MaxBackoff
+	}
+	return d
+}
+
+func (r *RemoteList) apply(snap listSnapshot, origin string) {
+	r.v.SetList(DomainList{Kind: r.opts.Kind, Source: r.opts.URL, Domains: snap.Domains})
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.snap = snap
+	r.status.Origin = origin
+	r.status.Version = snap.Version
+	r.status.Entries = len(snap.Domains)
+}
+
+func (r *RemoteList) succeed() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.checked = time.Now().UTC()
+	r.failures = 0
+	r.status.LastError = ""
+}
+
+func (r *RemoteList) fail(err error) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.failures++
+	r.status.LastError = err.Error()
+	return err
+}
+
+func (r *RemoteList) format() string {
+	if u, err := url.Parse(r.opts.URL); err == nil {
+		return ListFormatFromPath(u.Path)
+	}
+	return ListFormatFromPath(r.opts.URL)
+}
+
+func (r *RemoteList) readSnapshot() (listSnapshot, error) {
+	var snap listSnapshot
+	b, err := os.ReadFile(r.opts.SnapshotPath)
+	if err != nil {
+		return snap, err
+	}
+	if err := json.Unmarshal(b, &snap); err != nil {
+		return snap, err
+	}
+	if snap.URL != r.opts.URL {
+		return snap, fmt.Errorf("snapshot is for %s", snap.URL)
+	}
+	return snap, nil
+}
+
+// writeSnapshot replaces the snapshot atomically.
+func (r *RemoteList) writeSnapshot(snap listSnapshot) error {
+	b, err := json.Marshal(snap)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.opts.SnapshotPath), 0o755); err != nil {
+		return fmt.Errorf("failed to save %s snapshot: %w", r.opts.Kind, err)
+	}
+	tmp := r.opts.SnapshotPath + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("failed to save %s snapshot: %w", r.opts.Kind, err)
+	}
+	if err := os.Rename(tmp, r.opts.SnapshotPath); err != nil {
+		return fmt.Errorf("failed to save %s snapshot: %w", r.opts.Kind, err)
+	}
+	return nil
+}
+
+func digest(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:6])
+}
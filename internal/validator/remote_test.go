@@ -0,0 +1,125 @@
+package validator
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRemoteListRefresh(t *testing.T) {
+	var requests, notModified atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte(`["freebie.example"]`))
+	}))
+	defer srv.Close()
+
+	snapshot := filepath.Join(t.TempDir(), "lists", "free.json")
+	opts := DefaultOptions()
+	opts.Resolver = NewFakeResolver()
+	v := New(opts)
+	rl := NewRemoteList(v, RemoteListOptions{Kind: ListFree, URL: srv.URL + "/domains.json", SnapshotPath: snapshot})
+
+	ctx := context.Background()
+	if err := rl.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if err := rl.Refresh(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if requests.Load() != 2 || notModified.Load() != 1 {
+		t.Errorf("requests = %d, not modified = %d; want 2, 1", requests.Load(), notModified.Load())
+	}
+	if got := v.Validate("a@freebie.example").ClassifiedBy; got != RuleFreeList {
+		t.Errorf("classified_by = %q, want %q", got, RuleFreeList)
+	}
+	st := rl.Status()
+	if st.Origin != OriginRemote || st.Degraded || st.Version != `"v1"` || st.Entries != 1 || st.FetchedAt == nil || st.CheckedAt == nil {
+		t.Errorf("status = %+v", st)
+	}
+
+	// A restart with the server down serves the snapshot and keeps the ETag
+	srv.Close()
+	v2 := New(opts)
+	rl2 := NewRemoteList(v2, RemoteListOptions{Kind: ListFree, URL: srv.URL + "/domains.json", SnapshotPath: snapshot, Fallback: []byte(`["embedded.example"]`)})
+	if err := rl2.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	if got := v2.Validate("a@freebie.example").ClassifiedBy; got != RuleFreeList {
+		t.Errorf("from snapshot: classified_by = %q, want %q", got, RuleFreeList)
+	}
+	if err := rl2.Refresh(ctx); err == nil {
+		t.Error("refresh against a closed server succeeded")
+	}
+	st = rl2.Status()
+	if st.Origin != OriginSnapshot || st.Version != `"v1"` || st.Failures != 1 || st.LastError == "" {
+		t.Errorf("status after failure = %+v", st)
+	}
+}
+
+func TestRemoteListEmbeddedFallback(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Resolver = NewFakeResolver()
+	v := New(opts)
+	rl := NewRemoteList(v, RemoteListOptions{
+		Kind:         ListFree,
+		URL:          "http://127.0.0.1:0/domains.json",
+		SnapshotPath: filepath.Join(t.TempDir(), "missing.json"),
+		Fallback:     FreeProvidersFallback(),
+	})
+	if st := rl.Status(); !st.Degraded {
+		t.Errorf("status before bootstrap = %+v, want degraded", st)
+	}
+	if err := rl.Bootstrap(); err != nil {
+		t.Fatal(err)
+	}
+	// The embedded copy is only a subset of the upstream list
+	if st := rl.Status(); st.Origin != OriginEmbedded || !st.Degraded || st.Entries == 0 || st.FetchedAt != nil {
+		t.Errorf("status = %+v", st)
+	}
+	if got := v.Validate("a@gmx.de").ClassifiedBy; got != RuleFreeList {
+		t.Errorf("gmx.de classified_by = %q, want %q", got, RuleFreeList)
+	}
+}
+
+func TestRemoteListSizeLimit(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`["freebie.example", "another-freebie.example"]`))
+	}))
+	defer srv.Close()
+
+	opts := DefaultOptions()
+	opts.Resolver = NewFakeResolver()
+	v := New(opts)
+	rl := NewRemoteList(v, RemoteListOptions{Kind: ListFree, URL: srv.URL + "/domains.json", MaxBytes: 32})
+	if err := rl.Refresh(context.Background()); err == nil || !strings.Contains(err.Error(), "larger than 32 bytes") {
+		t.Fatalf("oversized list: err = %v", err)
+	}
+	if st := rl.Status(); st.Entries != 0 || st.Failures != 1 {
+		t.Errorf("status = %+v", st)
+	}
+	if got := v.Validate("a@freebie.example").ClassifiedBy; got == RuleFreeList {
+		t.Error("oversized list applied")
+	}
+}
+
+func TestRemoteListBackoff(t *testing.T) {
+	rl := NewRemoteList(nil, RemoteListOptions{MinBackoff: time.Second, MaxBackoff: 10 * time.Second})
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 8 * time.Second, 10 * time.Second, 10 * time.Second}
+	for i, w := range want {
+		if got := rl.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %s, want %s", i+1, got, w)
+		}
+	}
+}
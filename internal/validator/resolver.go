@@ -0,0 +1,165 @@
+package validator
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"sync"
+)
+
+// Resolver is the DNS interface used by the validator. Implementations must
+// be safe for concurrent use.
+type Resolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+	LookupHost(ctx context.Context, name string) ([]string, error) // A and AAAA
+	LookupTXT(ctx context.Context, name string) ([]string, error)
+}
+
+// NSResolver is implemented by resolvers that can look up a zone's
+// nameservers. net.Resolver implements it; without it the nameserver
+// heuristic for disposable domains is skipped.
+type NSResolver interface {
+	LookupNS(ctx context.Context, name string) ([]*net.NS, error)
+}
+
+var errNSUnsupported = errors.New("resolver does not support NS lookups")
+
+func lookupNS(ctx context.Context, r Resolver, name string) ([]*net.NS, error) {
+	nr, ok := r.(NSResolver)
+	if !ok {
+		return nil, errNSUnsupported
+	}
+	return nr.LookupNS(ctx, name)
+}
+
+// NewNetResolver returns a Resolver backed by net.Resolver. If server is
+// non-empty ("host" or "host:port") all queries are sent to that nameserver
+// instead of the system configuration.
+func NewNetResolver(server string) Resolver {
+	if server == "" {
+		return &net.Resolver{}
+	}
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, "53")
+	}
+	return &net.Resolver{
+		PreferGo: true,
+		Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, server)
+		},
+	}
+}
+
+// FakeResolver answers from in-memory records so the validator can be
+// exercised without network access. Names without records return a
+// not-found DNS error.
+type FakeResolver struct {
+	mu    sync.RWMutex
+	mx    map[string][]*net.MX
+	hosts map[string][]string
+	txt   map[string][]string
+	ns    map[string][]*net.NS
+}
+
+func NewFakeResolver() *FakeResolver {
+	return &FakeResolver{
+		mx:    make(map[string][]*net.MX),
+		hosts: make(map[string][]string),
+		txt:   make(map[string][]string),
+		ns:    make(map[string][]*net.NS),
+	}
+}
+
+func (f *FakeResolver) AddMX(name, host string, pref uint16) *FakeResolver {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name = dnsKey(name)
+	f.mx[name] = append(f.mx[name], &net.MX{Host: host, Pref: pref})
+	return f
+}
+
+func (f *FakeResolver) AddHost(name string, addrs ...string) *FakeResolver {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name = dnsKey(name)
+	f.hosts[name] = append(f.hosts[name], addrs...)
+	return f
+}
+
+func (f *FakeResolver) AddTXT(name string, records ...string) *FakeResolver {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name = dnsKey(name)
+	f.txt[name] = append(f.txt[name], records...)
+	return f
+}
+
+func (f *FakeResolver) AddNS(name string, hosts ...string) *FakeResolver {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	name = dnsKey(name)
+	for _, h := range hosts {
+		f.ns[name] = append(f.ns[name], &net.NS{Host: h})
+	}
+	return f
+}
+
+func (f *FakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if recs, ok := f.mx[dnsKey(name)]; ok {
+		return recs, nil
+	}
+	return nil, notFound(name)
+}
+
+func (f *FakeResolver) LookupHost(ctx context.Context, name string) ([]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if addrs, ok := f.hosts[dnsKey(name)]; ok {
+		return addrs, nil
+	}
+	return nil, notFound(name)
+}
+
+func (f *FakeResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if recs, ok := f.txt[dnsKey(name)]; ok {
+		return recs, nil
+	}
+	return nil, notFound(name)
+}
+
+func (f *FakeResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	if recs, ok := f.ns[dnsKey(name)]; ok {
+		return recs, nil
+	}
+	return nil, notFound(name)
+}
+
+// dnsKey is the case-insensitive, dot-less form of a DNS name used to key
+// records and cache entries.
+func dnsKey(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
+
+func notFound(name string) error {
+	return &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+}
@@ -0,0 +1,124 @@
+package validator
+
+import "strings"
+
+// Role account categories.
+const (
+	RoleGeneral   = "general"
+	RoleSales     = "sales"
+	RoleSupport   = "support"
+	RoleNoReply   = "noreply"
+	RoleAdmin     = "admin"
+	RoleBilling   = "billing"
+	RoleHR        = "hr"
+	RoleMarketing = "marketing"
+)
+
+// defaultRoleAccounts maps role local parts to their category. Matching
+// ignores case, "+tag" suffixes and the separators ".", "-" and "_"; a
+// trailing "*" turns a pattern into a prefix match.
+var defaultRoleAccounts = map[string]string{
+	// general inboxes
+	"info": RoleGeneral, "contact": RoleGeneral, "hello": RoleGeneral, "office": RoleGeneral,
+	"mail": RoleGeneral, "enquiries": RoleGeneral, "inquiries": RoleGeneral, "team": RoleGeneral,
+	"kontakt": RoleGeneral, "contacto": RoleGeneral, "contato": RoleGeneral, "contatti": RoleGeneral,
+	"informacion": RoleGeneral, "informatie": RoleGeneral, "buero": RoleGeneral, "bureau": RoleGeneral,
+	"почта": RoleGeneral, "инфо": RoleGeneral,
+
+	// sales
+	"sales": RoleSales, "orders": RoleSales, "order": RoleSales, "vertrieb": RoleSales,
+	"ventas": RoleSales, "vendas": RoleSales, "ventes": RoleSales, "vendite": RoleSales,
+	"verkoop": RoleSales, "prodazhi": RoleSales, "sale": RoleSales, "продажи": RoleSales,
+
+	// support
+	"support": RoleSupport, "help": RoleSupport, "helpdesk": RoleSupport, "service": RoleSupport,
+	"customerservice": RoleSupport, "care": RoleSupport, "kundenservice": RoleSupport,
+	"soporte": RoleSupport, "suporte": RoleSupport, "assistance": RoleSupport, "assistenza": RoleSupport,
+	"hilfe": RoleSupport, "ayuda": RoleSupport, "podderzhka": RoleSupport, "поддержка": RoleSupport,
+
+	// automated senders
+	"noreply": RoleNoReply, "noreply*": RoleNoReply, "donotreply": RoleNoReply, "donotreply*": RoleNoReply,
+	"mailerdaemon": RoleNoReply, "bounce*": RoleNoReply, "notifications": RoleNoReply,
+	"notification": RoleNoReply, "alerts": RoleNoReply, "nepasrepondre": RoleNoReply,
+	"keineantwort": RoleNoReply, "norespond": RoleNoReply, "noresponder": RoleNoReply,
+
+	// administrative
+	"admin": RoleAdmin, "administrator": RoleAdmin, "root": RoleAdmin, "postmaster": RoleAdmin,
+	"hostmaster": RoleAdmin, "webmaster": RoleAdmin, "abuse": RoleAdmin, "security": RoleAdmin,
+	"sysadmin": RoleAdmin, "it": RoleAdmin, "legal": RoleAdmin, "privacy": RoleAdmin,
+	"compliance": RoleAdmin, "dpo": RoleAdmin,
+
+	// finance
+	"billing": RoleBilling, "accounts": RoleBilling, "accounting": RoleBilling, "invoice": RoleBilling,
+	"invoices": RoleBilling, "finance": RoleBilling, "payments": RoleBilling, "rechnung": RoleBilling,
+	"buchhaltung": RoleBilling, "facturacion": RoleBilling, "facturas": RoleBilling,
+	"comptabilite": RoleBilling, "buhgalteria": RoleBilling, "бухгалтерия": RoleBilling,
+
+	// hiring
+	"hr": RoleHR, "jobs": RoleHR, "careers": RoleHR, "career": RoleHR, "recruiting": RoleHR,
+	"recruitment": RoleHR, "hiring": RoleHR, "talent": RoleHR, "karriere": RoleHR, "bewerbung": RoleHR,
+	"empleo": RoleHR, "empleos": RoleHR, "recrutement": RoleHR, "vacancies": RoleHR, "rabota": RoleHR,
+
+	// marketing and press
+	"marketing": RoleMarketing, "press": RoleMarketing, "media": RoleMarketing, "pr": RoleMarketing,
+	"news": RoleMarketing, "newsletter": RoleMarketing, "presse": RoleMarketing, "prensa": RoleMarketing,
+	"imprensa": RoleMarketing, "stampa": RoleMarketing, "partners": RoleMarketing, "partnerships": RoleMarketing,
+}
+
+// DefaultRoleAccounts returns a copy of the built-in role patterns.
+func DefaultRoleAccounts() map[string]string {
+	roles := make(map[string]string, len(defaultRoleAccounts))
+	for pattern, category := range defaultRoleAccounts {
+		roles[pattern] = category
+	}
+	return roles
+}
+
+// roleMatcher holds normalised role patterns split into exact and prefix
+// matches.
+type roleMatcher struct {
+	exact    map[string]string
+	prefixes map[string]string
+}
+
+func newRoleMatcher(patterns map[string]string) *roleMatcher {
+	m := &roleMatcher{exact: make(map[string]string), prefixes: make(map[string]string)}
+	for pattern, category := range patterns {
+		if strings.HasSuffix(pattern, "*") {
+			if p := normalizeRoleLocal(strings.TrimSuffix(pattern, "*")); p != "" {
+				m.prefixes[p] = category
+			}
+			continue
+		}
+		if p := normalizeRoleLocal(pattern); p != "" {
+			m.exact[p] = category
+		}
+	}
+	return m
+}
+
+// match returns the role category of local, or "" for personal mailboxes.
+func (m *roleMatcher) match(local string) string {
+	local = normalizeRoleLocal(local)
+	if category, ok := m.exact[local]; ok {
+		return category
+	}
+	best := ""
+	for prefix := range m.prefixes {
+		if strings.HasPrefix(local, prefix) && len(prefix) > len(best) {
+			best = prefix
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return m.prefixes[best]
+}
+
+func normalizeRoleLocal(local string) string {
+	local = strings.ToLower(strings.TrimSpace(local))
+	if i := strings.IndexByte(local, '+'); i >= 0 {
+		local = local[:i]
+	}
+	return strings.NewReplacer(".", "", "-", "", "_", "").Replace(local)
+}
@@ -0,0 +1,155 @@
+package validator
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// Reason codes explaining the risk score.
+const (
+	ReasonInvalidSyntax       = "INVALID_SYNTAX"
+	ReasonDisposableListMatch = "DISPOSABLE_LIST_MATCH"
+	ReasonDisposableHeuristic = "DISPOSABLE_HEURISTIC"
+	ReasonHomograph           = "HOMOGRAPH"
+	ReasonMixedScript         = "MIXED_SCRIPT"
+	ReasonDomainTypo          = "DOMAIN_TYPO"
+	ReasonNullMX              = "NULL_MX"
+	ReasonNoUsableMX          = "NO_USABLE_MX"
+	ReasonNoMX                = "NO_MX"
+	ReasonFreeProvider        = "FREE_PROVIDER"
+	ReasonRoleAccount         = "ROLE_ACCOUNT"
+	ReasonNoSPF               = "NO_SPF"
+	ReasonNoDMARC             = "NO_DMARC"
+	ReasonMailboxNotFound     = "MAILBOX_NOT_FOUND"
+	ReasonCatchAll            = "CATCH_ALL"
+	ReasonTimedOut            = "TIMED_OUT"
+)
+
+var defaultRiskWeights = map[string]int{
+	ReasonInvalidSyntax:       100,
+	ReasonDisposableListMatch: 100,
+	ReasonDisposableHeuristic: 60,
+	ReasonHomograph:           70,
+	ReasonMixedScript:         20,
+	ReasonDomainTypo:          40,
+	ReasonNullMX:              100,
+	ReasonNoUsableMX:          80,
+	ReasonNoMX:                60,
+	ReasonFreeProvider:        15,
+	ReasonRoleAccount:         20,
+	ReasonNoSPF:               10,
+	ReasonNoDMARC:             10,
+	ReasonMailboxNotFound:     100,
+	ReasonCatchAll:            15,
+	ReasonTimedOut:            10,
+}
+
+// DefaultRiskWeights returns a copy of the built-in reason weights.
+func DefaultRiskWeights() map[string]int {
+	weights := make(map[string]int, len(defaultRiskWeights))
+	for code, w := range defaultRiskWeights {
+		weights[code] = w
+	}
+	return weights
+}
+
+// LoadRiskWeights decodes a JSON object of reason code to weight. Only
+// known codes are accepted so that a typo does not silently score zero.
+func LoadRiskWeights(r io.Reader) (map[string]int, error) {
+	var weights map[string]int
+	if err := json.NewDecoder(r).Decode(&weights); err != nil {
+		return nil, fmt.Errorf("failed to decode risk weights: %w", err)
+	}
+	for code, w := range weights {
+		if _, ok := defaultRiskWeights[code]; !ok {
+			return nil, fmt.Errorf("unknown reason code %q", code)
+		}
+		if w < 0 || w > 100 {
+			return nil, fmt.Errorf("%s: weight must be between 0 and 100", code)
+		}
+	}
+	return weights, nil
+}
+
+// Score recomputes RiskScore and Reasons from the result's fields, for
+// callers that change a result after validation.
+func (v *Validator) Score(r *ValidationResult) {
+	v.data.Load().score(r)
+}
+
+// score sets the reasons found in r, heaviest first, and their summed
+// weight capped at 100. Reasons are listed even when their weight is zero.
+func (d *dataset) score(r *ValidationResult) {
+	reasons := riskReasons(r)
+	sort.SliceStable(reasons, func(i, j int) bool { return d.risk[reasons[i]] > d.risk[reasons[j]] })
+	total := 0
+	for _, code := range reasons {
+		total += d.risk[code]
+	}
+	r.RiskScore = min(total, 100)
+	r.Reasons = reasons
+}
+
+func riskReasons(r *ValidationResult) []string {
+	reasons := []string{}
+	if !r.SyntaxValid {
+		return append(reasons, ReasonInvalidSyntax)
+	}
+	if r.ClassifiedBy == RuleDisposableList {
+		reasons = append(reasons, ReasonDisposableListMatch)
+	} else if r.DisposableConfidence >= disposableThreshold {
+		reasons = append(reasons, ReasonDisposableHeuristic)
+	}
+	if r.HomographOf != "" {
+		reasons = append(reasons, ReasonHomograph)
+	} else if r.MixedScript {
+		reasons = append(reasons, ReasonMixedScript)
+	}
+	if r.Suggestion != "" {
+		reasons = append(reasons, ReasonDomainTypo)
+	}
+	timedOutDNS := false
+	for _, stage := range r.TimedOut {
+		timedOutDNS = timedOutDNS || stage == StageDNS
+	}
+	switch {
+	case r.IsDisposable:
+		// Disposable domains are not looked up
+	case r.NullMX:
+		reasons = append(reasons, ReasonNullMX)
+	case r.MXRecordsFound && !r.DomainValid:
+		reasons = append(reasons, ReasonNoUsableMX)
+	case !r.MXRecordsFound && !timedOutDNS && !isDomainLiteral(r.DomainASCII):
+		reasons = append(reasons, ReasonNoMX)
+	}
+	if r.IsPersonal {
+		reasons = append(reasons, ReasonFreeProvider)
+	}
+	if r.IsRole {
+		reasons = append(reasons, ReasonRoleAccount)
+	}
+	if r.MailAuth != nil {
+		if !r.MailAuth.SPF.Found {
+			reasons = append(reasons, ReasonNoSPF)
+		}
+		if !r.MailAuth.DMARC.Valid() {
+			reasons = append(reasons, ReasonNoDMARC)
+		}
+	}
+	if r.SMTP != nil && r.SMTP.Status == SMTPUndeliverable {
+		reasons = append(reasons, ReasonMailboxNotFound)
+	}
+	if r.IsCatchAll {
+		reasons = append(reasons, ReasonCatchAll)
+	}
+	if len(r.TimedOut) > 0 {
+		reasons = append(reasons, ReasonTimedOut)
+	}
+	return reasons
+}
+
+func isDomainLiteral(domain string) bool {
+	return len(domain) > 0 && domain[0] == '['
+}
@@ -0,0 +1,68 @@
+package validator
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestRiskScore(t *testing.T) {
+	opts := DefaultOptions()
+	opts.MailAuth = &MailAuthOptions{DKIMSelectors: []string{}}
+	opts.Resolver = NewFakeResolver().
+		AddMX("gmail.com", "gmail-smtp-in.l.google.com.", 5).
+		AddTXT("gmail.com", "v=spf1 redirect=_spf.google.com").
+		AddTXT("_spf.google.com", "v=spf1 -all").
+		AddTXT("_dmarc.gmail.com", "v=DMARC1; p=none").
+		AddMX("acme.example", "mx.acme.example.", 10).
+		AddTXT("acme.example", "v=spf1 -all").
+		AddTXT("_dmarc.acme.example", "v=DMARC1; p=reject").
+		AddMX("parked.example", "mx.parked.example.", 10).
+		AddMX("nomail.example", ".", 0).
+		AddHost("gmail-smtp-in.l.google.com", "142.250.27.26").
+		AddHost("mx.acme.example", "64.233.184.26").
+		AddHost("mx.parked.example", "64.233.184.27")
+	v := New(opts)
+
+	tests := []struct {
+		email   string
+		score   int
+		reasons []string
+	}{
+		{"jane@acme.example", 0, []string{}},
+		{"john@gmail.com", 15, []string{ReasonFreeProvider}},
+		{"info@acme.example", 20, []string{ReasonRoleAccount}},
+		{"info@parked.example", 40, []string{ReasonRoleAccount, ReasonNoSPF, ReasonNoDMARC}},
+		{"eve@yopmail.com", 100, []string{ReasonDisposableListMatch}},
+		{"bob@nomail.example", 100, []string{ReasonNullMX, ReasonNoSPF, ReasonNoDMARC}},
+		{"bob@missing.example", 60, []string{ReasonNoMX}},
+		{"not-an-email", 100, []string{ReasonInvalidSyntax}},
+	}
+	for _, tt := range tests {
+		r := v.Validate(tt.email)
+		if r.RiskScore != tt.score || !reflect.DeepEqual(r.Reasons, tt.reasons) {
+			t.Errorf("%s: score %d reasons %v, want %d %v", tt.email, r.RiskScore, r.Reasons, tt.score, tt.reasons)
+		}
+	}
+
+	// Weights come from the options; a zero weight keeps the reason listed
+	opts.RiskWeights = DefaultRiskWeights()
+	opts.RiskWeights[ReasonFreeProvider] = 0
+	opts.RiskWeights[ReasonRoleAccount] = 50
+	v = New(opts)
+	if r := v.Validate("info@gmail.com"); r.RiskScore != 50 || !reflect.DeepEqual(r.Reasons, []string{ReasonRoleAccount, ReasonFreeProvider}) {
+		t.Errorf("custom weights: score %d reasons %v", r.RiskScore, r.Reasons)
+	}
+}
+
+func TestLoadRiskWeights(t *testing.T) {
+	weights, err := LoadRiskWeights(strings.NewReader(`{"FREE_PROVIDER": 0, "NO_DMARC": 25}`))
+	if err != nil || len(weights) != 2 || weights[ReasonNoDMARC] != 25 {
+		t.Fatalf("LoadRiskWeights = %v, %v", weights, err)
+	}
+	for _, in := range []string{`{"FREE_PROVIDRE": 10}`, `{"NO_MX": 150}`, `[]`} {
+		if _, err := LoadRiskWeights(strings.NewReader(in)); err == nil {
+			t.Errorf("LoadRiskWeights(%s) succeeded", in)
+		}
+	}
+}
@@ -0,0 +1,300 @@
+package validator
+
+import (
+	"container/list"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SMTP probe outcomes.
+const (
+	SMTPDeliverable   = "deliverable"
+	SMTPUndeliverable = "undeliverable"
+	SMTPUnknown       = "unknown"
+)
+
+type SMTPOptions struct {
+	HeloName      string
+	MailFrom      string
+	Port          string        // defaults to 25
+	MaxConnsPerMX int           // concurrent connections per MX host, defaults to 2
+	CatchAllTTL   time.Duration // how long a domain's catch-all status is cached, defaults to 24h
+	CatchAllSize  int           // LRU size cap of the catch-all cache, defaults to 10000
+	ConnTimeout   time.Duration // bounds each connection, even when the caller has no deadline; defaults to 30s
+}
+
+// Deliverability confidence reported with each probe outcome.
+const (
+	confidenceConfirmed = 0.95
+	confidenceCatchAll  = 0.5
+)
+
+// SMTPProber checks mailbox existence by running EHLO / MAIL FROM / RCPT TO
+// against a domain's MX without ever sending DATA. When a mailbox is
+// accepted it also tries a random local part to detect catch-all domains.
+type SMTPProber struct {
+	opts SMTPOptions
+
+	mu       sync.Mutex
+	slots    map[string]*mxSlot       // per-MX connection semaphores, dropped when idle
+	lru      *list.List               // of *catchAllEntry, most recent first
+	catchAll map[string]*list.Element // per-domain catch-all status
+}
+
+type mxSlot struct {
+	sem   chan struct{}
+	users int // holders and waiters; guarded by SMTPProber.mu
+}
+
+type catchAllEntry struct {
+	domain   string
+	catchAll bool
+	expires  time.Time
+}
+
+func NewSMTPProber(opts SMTPOptions) *SMTPProber {
+	if opts.HeloName == "" {
+		opts.HeloName = "localhost"
+	}
+	if opts.MailFrom == "" {
+		opts.MailFrom = "verify@" + opts.HeloName
+	}
+	if opts.Port == "" {
+		opts.Port = "25"
+	}
+	if opts.MaxConnsPerMX <= 0 {
+		opts.MaxConnsPerMX = 2
+	}
+	if opts.CatchAllTTL <= 0 {
+		opts.CatchAllTTL = 24 * time.Hour
+	}
+	if opts.CatchAllSize <= 0 {
+		opts.CatchAllSize = 10000
+	}
+	if opts.ConnTimeout <= 0 {
+		opts.ConnTimeout = 30 * time.Second
+	}
+	return &SMTPProber{
+		opts:     opts,
+		slots:    make(map[string]*mxSlot),
+		lru:      list.New(),
+		catchAll: make(map[string]*list.Element),
+	}
+}
+
+// Probe asks the highest-priority of the diagnosed MX hosts whether it
+// accepts email.
+func (p *SMTPProber) Probe(ctx context.Context, hosts []MXHost, email string) *SMTPCheck {
+	if len(hosts) == 0 {
+		return &SMTPCheck{Status: SMTPUnknown, Message: "no MX records"}
+	}
+	sorted := append([]MXHost(nil), hosts...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Pref < sorted[j].Pref })
+	return p.ProbeHost(ctx, sorted[0], email)
+}
+
+// ProbeHost connects to the host's diagnosed addresses in turn. The name is
+// only dialled when it has no addresses, so a zone cannot swap in another
+// target between the MX check and the probe. The connection never outlives
+// ConnTimeout, so a tarpitting server cannot hold a slot forever.
+func (p *SMTPProber) ProbeHost(ctx context.Context, host MXHost, email string) *SMTPCheck {
+	check := &SMTPCheck{Status: SMTPUnknown, MXHost: host.Host}
+
+	release, err := p.acquire(ctx, host.Host)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, p.opts.ConnTimeout)
+	defer cancel()
+	conn, err := p.dial(ctx, host)
+	if err != nil {
+		check.Message = err.Error()
+		return check
+	}
+	defer conn.Close()
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+	dl, _ := ctx.Deadline()
+	conn.SetDeadline(dl)
+
+	c, err := smtp.NewClient(conn, host.Host)
+	if err != nil {
+		return p.fail(ctx, check, err)
+	}
+	defer c.Close()
+	if err := c.Hello(p.opts.HeloName); err != nil {
+		return p.fail(ctx, check, err)
+	}
+	if err := c.Mail(p.opts.MailFrom); err != nil {
+		return p.fail(ctx, check, err)
+	}
+
+	defer c.Quit()
+	err = c.Rcpt(email)
+	if err != nil {
+		var protoErr *textproto.Error
+		if errors.As(err, &protoErr) {
+			check.Code = protoErr.Code
+			check.Message = protoErr.Msg
+			if protoErr.Code >= 500 && protoErr.Code < 600 {
+				check.Status = SMTPUndeliverable
+				check.Confidence = confidenceConfirmed
+			}
+			return check
+		}
+		return p.fail(ctx, check, err)
+	}
+
+	check.Status = SMTPDeliverable
+	check.Code = 250
+	check.Confidence = confidenceConfirmed
+
+	domain := email[strings.LastIndex(email, "@")+1:]
+	catchAll, known := p.cachedCatchAll(domain)
+	if !known {
+		catchAll, known = p.testCatchAll(c, domain)
+		if known {
+			p.storeCatchAll(domain, catchAll)
+		}
+	}
+	if catchAll {
+		check.CatchAll = true
+		check.Confidence = confidenceCatchAll
+	}
+	return check
+}
+
+func (p *SMTPProber) dial(ctx context.Context, host MXHost) (net.Conn, error) {
+	addrs := host.Addresses
+	if len(addrs) == 0 {
+		addrs = []string{host.Host}
+	}
+	var d net.Dialer
+	var err error
+	for _, a := range addrs {
+		var conn net.Conn
+		if conn, err = d.DialContext(ctx, "tcp", net.JoinHostPort(a, p.opts.Port)); err == nil {
+			return conn, nil
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	return nil, err
+}
+
+// testCatchAll offers a random local part in the same transaction. Only a
+// definite 2xx or 5xx answer is conclusive.
+func (p *SMTPProber) testCatchAll(c *smtp.Client, domain string) (catchAll bool, known bool) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return false, false
+	}
+	err := c.Rcpt("nx-" + hex.EncodeToString(b) + "@" + domain)
+	if err == nil {
+		return true, true
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) && protoErr.Code >= 500 && protoErr.Code < 600 {
+		return false, true
+	}
+	return false, false
+}
+
+// cachedCatchAll returns a domain's unexpired catch-all status; expired
+// entries are evicted on the way.
+func (p *SMTPProber) cachedCatchAll(domain string) (catchAll bool, known bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	el, ok := p.catchAll[domain]
+	if !ok {
+		return false, false
+	}
+	e := el.Value.(*catchAllEntry)
+	if time.Now().After(e.expires) {
+		p.lru.Remove(el)
+		delete(p.catchAll, domain)
+		return false, false
+	}
+	p.lru.MoveToFront(el)
+	return e.catchAll, true
+}
+
+// storeCatchAll records a domain's catch-all status, evicting the least
+// recently used entries beyond CatchAllSize.
+func (p *SMTPProber) storeCatchAll(domain string, catchAll bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	e := &catchAllEntry{domain: domain, catchAll: catchAll, expires: time.Now().Add(p.opts.CatchAllTTL)}
+	if el, ok := p.catchAll[domain]; ok {
+		el.Value = e
+		p.lru.MoveToFront(el)
+		return
+	}
+	p.catchAll[domain] = p.lru.PushFront(e)
+	for p.lru.Len() > p.opts.CatchAllSize {
+		oldest := p.lru.Back()
+		p.lru.Remove(oldest)
+		delete(p.catchAll, oldest.Value.(*catchAllEntry).domain)
+	}
+}
+
+// fail records a protocol or connection error; the mailbox state stays
+// unknown.
+func (p *SMTPProber) fail(ctx context.Context, check *SMTPCheck, err error) *SMTPCheck {
+	if ctxErr := ctx.Err(); ctxErr != nil {
+		err = ctxErr
+	}
+	var protoErr *textproto.Error
+	if errors.As(err, &protoErr) {
+		check.Code = protoErr.Code
+		check.Message = protoErr.Msg
+	} else {
+		check.Message = err.Error()
+	}
+	return check
+}
+
+// acquire takes one of the host's MaxConnsPerMX connection slots, waiting
+// until one is free. A host's semaphore is dropped once nobody holds or
+// waits for it.
+func (p *SMTPProber) acquire(ctx context.Context, host string) (func(), error) {
+	p.mu.Lock()
+	slot, ok := p.slots[host]
+	if !ok {
+		slot = &mxSlot{sem: make(chan struct{}, p.opts.MaxConnsPerMX)}
+		p.slots[host] = slot
+	}
+	slot.users++
+	p.mu.Unlock()
+
+	leave := func() {
+		p.mu.Lock()
+		if slot.users--; slot.users == 0 {
+			delete(p.slots, host)
+		}
+		p.mu.Unlock()
+	}
+	select {
+	case slot.sem <- struct{}{}:
+		return func() {
+			<-slot.sem
+			leave()
+		}, nil
+	case <-ctx.Done():
+		leave()
+		return nil, ctx.Err()
+	}
+}
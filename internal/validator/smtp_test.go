@@ -0,0 +1,310 @@
+package validator
+
+import (
+	"bufio"
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeSMTPServer speaks just enough SMTP for the RCPT TO probe. Recipients
+// in mailboxes are accepted, everything else is rejected with 550; when
+// acceptAll is set every recipient is accepted. delay holds back the
+// greeting of every connection.
+type fakeSMTPServer struct {
+	ln        net.Listener
+	mailboxes map[string]bool
+	acceptAll bool
+	delay     time.Duration
+
+	mu      sync.Mutex
+	rcpts   []string
+	helo    string
+	from    string
+	open    int
+	maxOpen int
+}
+
+func startFakeSMTP(t *testing.T, mailboxes ...string) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &fakeSMTPServer{ln: ln, mailboxes: make(map[string]bool)}
+	for _, m := range mailboxes {
+		s.mailboxes[strings.ToLower(m)] = true
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) port() string {
+	_, port, _ := net.SplitHostPort(s.ln.Addr().String())
+	return port
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	s.mu.Lock()
+	s.open++
+	s.maxOpen = max(s.maxOpen, s.open)
+	delay := s.delay
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.open--
+		s.mu.Unlock()
+	}()
+	time.Sleep(delay)
+	r := bufio.NewReader(conn)
+	reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	reply("220 fake.test ESMTP")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimSpace(line)
+		cmd := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(cmd, "EHLO"), strings.HasPrefix(cmd, "HELO"):
+			s.mu.Lock()
+			s.helo = strings.TrimSpace(line[4:])
+			s.mu.Unlock()
+			reply("250 fake.test")
+		case strings.HasPrefix(cmd, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			s.mu.Unlock()
+			reply("250 OK")
+		case strings.HasPrefix(cmd, "RCPT TO:"):
+			rcpt := strings.ToLower(strings.Trim(line[len("RCPT TO:"):], "<> "))
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, rcpt)
+			s.mu.Unlock()
+			if s.acceptAll || s.mailboxes[rcpt] {
+				reply("250 OK")
+			} else {
+				reply("550 5.1.1 No such user")
+			}
+		case cmd == "RSET", cmd == "NOOP":
+			reply("250 OK")
+		case cmd == "QUIT":
+			reply("221 Bye")
+			return
+		case strings.HasPrefix(cmd, "DATA"):
+			reply("554 DATA not allowed in tests")
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+func TestSMTPProbe(t *testing.T) {
+	srv := startFakeSMTP(t, "jane@acme.example")
+	opts := DefaultOptions()
+	opts.Resolver = NewFakeResolver().
+		AddMX("acme.example", "mx.acme.example.", 10).
+		AddMX("acme.example", "192.0.2.1.", 20).
+		AddHost("mx.acme.example", "127.0.0.1")
+	opts.AllowPrivateMX = true
+	opts.SMTP = NewSMTPProber(SMTPOptions{HeloName: "checker.test", MailFrom: "probe@checker.test", Port: srv.port()})
+	opts.Timeouts.SMTP = 2 * time.Second
+	v := New(opts)
+
+	tests := []struct {
+		email  string
+		status string
+		code   int
+		valid  bool
+	}{
+		{"jane@acme.example", SMTPDeliverable, 250, true},
+		{"nobody@acme.example", SMTPUndeliverable, 550, false},
+	}
+	for _, tt := range tests {
+		r := v.Validate(tt.email)
+		if r.SMTP == nil {
+			t.Fatalf("%s: no SMTP result", tt.email)
+		}
+		if r.SMTP.Status != tt.status || r.SMTP.Code != tt.code || r.Valid != tt.valid || r.SMTP.MXHost != "mx.acme.example" {
+			t.Errorf("%s: smtp=%+v valid=%v, want status=%s code=%d valid=%v", tt.email, r.SMTP, r.Valid, tt.status, tt.code, tt.valid)
+		}
+	}
+
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.helo != "checker.test" || srv.from != "probe@checker.test" {
+		t.Errorf("server saw HELO %q and MAIL FROM %q", srv.helo, srv.from)
+	}
+}
+
+// hostErrorResolver fails the address lookups of one name without a
+// definitive answer.
+type hostErrorResolver struct {
+	*FakeResolver
+	host string
+}
+
+func (r hostErrorResolver) LookupHost(ctx context.Context, name string) ([]string, error) {
+	if name == r.host {
+		return nil, &net.DNSError{Err: "server misbehaving", Name: name, IsTemporary: true}
+	}
+	return r.FakeResolver.LookupHost(ctx, name)
+}
+
+func TestSMTPProbeSkipsUnknownMX(t *testing.T) {
+	srv := startFakeSMTP(t, "jane@acme.example")
+	opts := DefaultOptions()
+	opts.Resolver = hostErrorResolver{NewFakeResolver().AddMX("acme.example", "mx.acme.example.", 10), "mx.acme.example"}
+	opts.SMTP = NewSMTPProber(SMTPOptions{Port: srv.port()})
+	opts.Timeouts.SMTP = 2 * time.Second
+
+	r := New(opts).Validate("jane@acme.example")
+	if len(r.MXHosts) != 1 || r.MXHosts[0].Status != MXUnknown {
+		t.Fatalf("mx_hosts = %+v, want one unknown host", r.MXHosts)
+	}
+	if !r.DomainValid {
+		t.Error("unknown MX host not given the benefit of the doubt")
+	}
+	if r.SMTP != nil {
+		t.Errorf("unknown MX host probed: %+v", r.SMTP)
+	}
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if len(srv.rcpts) != 0 {
+		t.Errorf("server saw RCPTs %v", srv.rcpts)
+	}
+}
+
+func TestSMTPProbeConnectionFailure(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	ln.Close()
+
+	p := NewSMTPProber(SMTPOptions{Port: port})
+	check := p.ProbeHost(context.Background(), MXHost{Host: "127.0.0.1", Addresses: []string{"127.0.0.1"}}, "jane@acme.example")
+	if check.Status != SMTPUnknown {
+		t.Errorf("status = %q, want %q", check.Status, SMTPUnknown)
+	}
+}
+
+func TestSMTPCatchAll(t *testing.T) {
+	strict := startFakeSMTP(t, "jane@strict.example")
+	open := startFakeSMTP(t)
+	open.acceptAll = true
+
+	for _, tt := range []struct {
+		srv        *fakeSMTPServer
+		domain     string
+		catchAll   bool
+		confidence float64
+	}{
+		{strict, "strict.example", false, confidenceConfirmed},
+		{open, "open.example", true, confidenceCatchAll},
+	} {
+		p := NewSMTPProber(SMTPOptions{Port: tt.srv.port()})
+		for i := 0; i < 2; i++ {
+			check := p.ProbeHost(context.Background(), MXHost{Host: "127.0.0.1", Addresses: []string{"127.0.0.1"}}, "jane@"+tt.domain)
+			if check.Status != SMTPDeliverable || check.CatchAll != tt.catchAll || check.Confidence != tt.confidence {
+				t.Errorf("%s probe %d: %+v, want catch_all=%v confidence=%v", tt.domain, i, check, tt.catchAll, tt.confidence)
+			}
+		}
+		// The random local part is only tried once; the second probe is cached
+		tt.srv.mu.Lock()
+		if len(tt.srv.rcpts) != 3 {
+			t.Errorf("%s: server saw RCPTs %v, want 3", tt.domain, tt.srv.rcpts)
+		}
+		tt.srv.mu.Unlock()
+	}
+}
+
+func TestSMTPMaxConnsPerMX(t *testing.T) {
+	srv := startFakeSMTP(t, "jane@acme.example")
+	srv.mu.Lock()
+	srv.delay = 50 * time.Millisecond
+	srv.mu.Unlock()
+	p := NewSMTPProber(SMTPOptions{Port: srv.port(), MaxConnsPerMX: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 6; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			check := p.ProbeHost(context.Background(), MXHost{Host: "127.0.0.1", Addresses: []string{"127.0.0.1"}}, "jane@acme.example")
+			if check.Status != SMTPDeliverable {
+				t.Errorf("probe: %+v", check)
+			}
+		}()
+	}
+	wg.Wait()
+
+	srv.mu.Lock()
+	if srv.maxOpen != 2 {
+		t.Errorf("server saw %d concurrent connections, want 2", srv.maxOpen)
+	}
+	srv.mu.Unlock()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(p.slots) != 0 {
+		t.Errorf("idle slots kept: %v", p.slots)
+	}
+}
+
+func TestSMTPConnTimeout(t *testing.T) {
+	srv := startFakeSMTP(t, "jane@acme.example")
+	srv.mu.Lock()
+	srv.delay = 2 * time.Second
+	srv.mu.Unlock()
+	p := NewSMTPProber(SMTPOptions{Port: srv.port(), ConnTimeout: 100 * time.Millisecond})
+
+	start := time.Now()
+	check := p.ProbeHost(context.Background(), MXHost{Host: "127.0.0.1", Addresses: []string{"127.0.0.1"}}, "jane@acme.example")
+	if check.Status != SMTPUnknown {
+		t.Errorf("status = %q, want %q", check.Status, SMTPUnknown)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("probe without a deadline took %v", elapsed)
+	}
+}
+
+func TestSMTPCatchAllEviction(t *testing.T) {
+	srv := startFakeSMTP(t)
+	srv.acceptAll = true
+	probe := func(p *SMTPProber, domain string) {
+		p.ProbeHost(context.Background(), MXHost{Host: "127.0.0.1", Addresses: []string{"127.0.0.1"}}, "jane@"+domain)
+	}
+
+	p := NewSMTPProber(SMTPOptions{Port: srv.port(), CatchAllSize: 2})
+	for _, d := range []string{"a.example", "b.example", "a.example", "c.example"} {
+		probe(p, d)
+	}
+	if _, known := p.cachedCatchAll("b.example"); known {
+		t.Error("least recently used entry not evicted")
+	}
+	if _, known := p.cachedCatchAll("a.example"); !known {
+		t.Error("recently used entry evicted")
+	}
+
+	p = NewSMTPProber(SMTPOptions{Port: srv.port(), CatchAllTTL: time.Nanosecond})
+	probe(p, "a.example")
+	time.Sleep(time.Millisecond)
+	if _, known := p.cachedCatchAll("a.example"); known {
+		t.Error("expired entry reported")
+	}
+	if len(p.catchAll) != 0 || p.lru.Len() != 0 {
+		t.Errorf("expired entry kept: %d entries", len(p.catchAll))
+	}
+}
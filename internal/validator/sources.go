@@ -0,0 +1,240 @@
+package validator
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ListKind names one of the classification lists.
+type ListKind string
+
+const (
+	ListCorporate         ListKind = "corporate"
+	ListDisposable        ListKind = "disposable"
+	ListPersonal          ListKind = "personal"
+	ListFree              ListKind = "free"
+	ListCorporateOverride ListKind = "corporate_override"
+	ListPersonalOverride  ListKind = "personal_override"
+)
+
+// ParseListKind accepts the names above, case-insensitively.
+func ParseListKind(s string) (ListKind, error) {
+	kind := ListKind(strings.ToLower(strings.TrimSpace(s)))
+	switch kind {
+	case ListCorporate, ListDisposable, ListPersonal, ListFree, ListCorporateOverride, ListPersonalOverride:
+		return kind, nil
+	}
+	return "", fmt.Errorf("unknown list %q", s)
+}
+
+// Sources of the lists built into Options.
+const (
+	SourceBuiltin   = "builtin"
+	SourceCompanies = "companies"
+)
+
+// DomainList is a named source of list entries. The source is reported as
+// list_source when one of its domains decides the classification. Values
+// are canonical corporate domains for ListCorporate and ignored otherwise.
+type DomainList struct {
+	Kind    ListKind
+	Source  string
+	Domains map[string]string
+}
+
+// List file formats, chosen by file extension.
+const (
+	ListFormatText = "text"
+	ListFormatJSON = "json"
+	ListFormatCSV  = "csv"
+)
+
+// ListFormatFromPath maps .json and .csv files to their format; anything
+// else is read as text.
+func ListFormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return ListFormatJSON
+	case ".csv":
+		return ListFormatCSV
+	default:
+		return ListFormatText
+	}
+}
+
+// ReadDomainList parses a domain list. Text has one domain per line, with
+// an optional canonical corporate domain after whitespace, and "#"
+// comments. JSON is an array of domains or an object mapping domain to
+// canonical domain. CSV uses the "domain" and "corporate_domain" columns
+// when a header names them, and the first two columns otherwise.
+func ReadDomainList(r io.Reader, format string) (map[string]string, error) {
+	domains := make(map[string]string)
+	switch format {
+	case ListFormatText:
+		sc := bufio.NewScanner(r)
+		for sc.Scan() {
+			line, _, _ := strings.Cut(sc.Text(), "#")
+			fields := strings.Fields(line)
+			switch len(fields) {
+			case 0:
+			case 1:
+				domains[fields[0]] = ""
+			default:
+				domains[fields[0]] = fields[1]
+			}
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	case ListFormatJSON:
+		raw, err := io.ReadAll(r)
+		if err != nil {
+			return nil, err
+		}
+		var list []string
+		if err := json.Unmarshal(raw, &list); err == nil {
+			for _, d := range list {
+				domains[d] = ""
+			}
+			break
+		}
+		if err := json.Unmarshal(raw, &domains); err != nil {
+			return nil, errors.New("expected a JSON array of domains or an object of domain to corporate domain")
+		}
+	case ListFormatCSV:
+		cr := csv.NewReader(r)
+		cr.FieldsPerRecord = -1
+		cr.Comment = '#'
+		domainCol, canonicalCol := 0, 1
+		first := true
+		for {
+			rec, err := cr.Read()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, fmt.Errorf("invalid CSV: %w", err)
+			}
+			if first {
+				first = false
+				if idx := columnIndex(rec, "domain"); idx >= 0 {
+					domainCol, canonicalCol = idx, columnIndex(rec, "corporate_domain")
+					continue
+				}
+			}
+			if domainCol >= len(rec) {
+				continue
+			}
+			canonical := ""
+			if canonicalCol >= 0 && canonicalCol < len(rec) {
+				canonical = rec[canonicalCol]
+			}
+			domains[rec[domainCol]] = canonical
+		}
+	default:
+		return nil, fmt.Errorf("unsupported list format %q", format)
+	}
+	return domains, nil
+}
+
+// ReadListPath loads a list file, or every .txt, .list, .json and .csv
+// file in a directory in name order. Each file becomes its own source named
+// after its path.
+func ReadListPath(kind ListKind, path string) ([]DomainList, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, err
+		}
+		files = files[:0]
+		for _, e := range entries {
+			switch strings.ToLower(filepath.Ext(e.Name())) {
+			case ".txt", ".list", ".json", ".csv":
+				if !e.IsDir() {
+					files = append(files, filepath.Join(path, e.Name()))
+				}
+			}
+		}
+		sort.Strings(files)
+	}
+
+	lists := make([]DomainList, 0, len(files))
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, err
+		}
+		domains, err := ReadDomainList(f, ListFormatFromPath(file))
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file, err)
+		}
+		lists = append(lists, DomainList{Kind: kind, Source: "file:" + file, Domains: domains})
+	}
+	return lists, nil
+}
+
+func columnIndex(rec []string, name string) int {
+	for i, col := range rec {
+		if strings.EqualFold(strings.TrimSpace(col), name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// ListEntry is one domain of an effective list and the source defining it.
+type ListEntry struct {
+	Domain          string `json:"domain"`
+	CorporateDomain string `json:"corporate_domain,omitempty"`
+	Source          string `json:"source"`
+}
+
+// Entries returns the effective entries of a list sorted by domain. A
+// domain defined by several sources is reported once, with the source that
+// decides it (see dataset.source).
+func (v *Validator) Entries(kind ListKind) []ListEntry {
+	d := v.data.Load()
+	seen := make(map[string]bool)
+	var entries []ListEntry
+	for _, l := range d.lists[kind] {
+		for domain := range l.domains {
+			if !seen[domain] {
+				seen[domain] = true
+				entries = append(entries, d.entry(kind, domain))
+			}
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Domain < entries[j].Domain })
+	return entries
+}
+
+// Entry returns the effective entry of domain in a list.
+func (v *Validator) Entry(kind ListKind, domain string) (ListEntry, bool) {
+	d := v.data.Load()
+	if d.source(kind, domain) == "" {
+		return ListEntry{}, false
+	}
+	return d.entry(kind, domain), true
+}
+
+func (d *dataset) entry(kind ListKind, domain string) ListEntry {
+	e := ListEntry{Domain: domain, Source: d.source(kind, domain)}
+	if kind == ListCorporate {
+		e.CorporateDomain = d.corporate[domain]
+	}
+	return e
+}
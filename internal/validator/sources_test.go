@@ -0,0 +1,119 @@
+package validator
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadDomainList(t *testing.T) {
+	tests := []struct {
+		format string
+		input  string
+		want   map[string]string
+	}{
+		{ListFormatText, "# disposable\nTrash.example\n\nspam.example  # added 2024\n", map[string]string{"Trash.example": "", "spam.example": ""}},
+		{ListFormatText, "acme.example\nacme-labs.example acme.example\n", map[string]string{"acme.example": "", "acme-labs.example": "acme.example"}},
+		{ListFormatJSON, `["a.example", "b.example"]`, map[string]string{"a.example": "", "b.example": ""}},
+		{ListFormatJSON, `{"acme-labs.example": "acme.example"}`, map[string]string{"acme-labs.example": "acme.example"}},
+		{ListFormatCSV, "corporate_domain,domain\nacme.example,acme-labs.example\n", map[string]string{"acme-labs.example": "acme.example"}},
+		{ListFormatCSV, "a.example\nb.example,\n", map[string]string{"a.example": "", "b.example": ""}},
+	}
+	for _, tt := range tests {
+		got, err := ReadDomainList(strings.NewReader(tt.input), tt.format)
+		if err != nil {
+			t.Errorf("%s %q: %v", tt.format, tt.input, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s %q = %v, want %v", tt.format, tt.input, got, tt.want)
+		}
+	}
+
+	if _, err := ReadDomainList(strings.NewReader(`{"a": 1}`), ListFormatJSON); err == nil {
+		t.Error("invalid JSON list accepted")
+	}
+}
+
+func TestListFilesProvenance(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	if err := os.Mkdir(filepath.Join(dir, "disposable"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	first := write("disposable/a.txt", "burner.example\n")
+	second := write("disposable/b.json", `["burner.example", "throwaway.example"]`)
+	write("disposable/README.md", "ignored.example\n")
+	corporate := write("corporate.csv", "domain,corporate_domain\nacme-labs.example,acme.example\n")
+
+	opts := DefaultOptions()
+	opts.Resolver = NewFakeResolver().AddMX("acme-labs.example", "mx.acme.example.", 10).AddHost("mx.acme.example", "64.233.184.26")
+	for _, p := range []struct {
+		kind ListKind
+		path string
+	}{{ListDisposable, filepath.Join(dir, "disposable")}, {ListCorporate, corporate}} {
+		lists, err := ReadListPath(p.kind, p.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts.Lists = append(opts.Lists, lists...)
+	}
+	v := New(opts)
+
+	tests := []struct {
+		email, providerType, source string
+	}{
+		{"a@burner.example", "disposable", "file:" + first},
+		{"a@throwaway.example", "disposable", "file:" + second},
+		{"a@ignored.example", "unknown", ""},
+		{"a@acme-labs.example", "corporate", "file:" + corporate},
+		{"a@yopmail.com", "disposable", SourceBuiltin},
+		{"a@fb.com", "corporate", SourceCompanies},
+	}
+	for _, tt := range tests {
+		res := v.Validate(tt.email)
+		if res.ProviderType != tt.providerType || res.ListSource != tt.source {
+			t.Errorf("%s: got (%q, %q), want (%q, %q)", tt.email, res.ProviderType, res.ListSource, tt.providerType, tt.source)
+		}
+	}
+	if got := v.Validate("a@acme-labs.example").CorporateDomain; got != "acme.example" {
+		t.Errorf("corporate_domain = %q, want acme.example", got)
+	}
+
+	// Replacing a source drops its old entries but keeps the others
+	v.SetList(DomainList{Kind: ListDisposable, Source: "file:" + first, Domains: map[string]string{}})
+	if got := v.Validate("a@burner.example").ListSource; got != "file:"+second {
+		t.Errorf("after reload: list_source %q, want %q", got, "file:"+second)
+	}
+}
+
+func TestEntriesCorporateSource(t *testing.T) {
+	v := New(DefaultOptions())
+	v.SetList(DomainList{Kind: ListCorporate, Source: "file:a", Domains: map[string]string{"acme-labs.example": "acme.example"}})
+	v.SetList(DomainList{Kind: ListCorporate, Source: "file:b", Domains: map[string]string{"acme-labs.example": "acme-group.example"}})
+
+	// The entry names the source whose corporate domain is in effect
+	e, ok := v.Entry(ListCorporate, "acme-labs.example")
+	if !ok || e.Source != "file:b" || e.CorporateDomain != "acme-group.example" {
+		t.Errorf("Entry = %+v, %v", e, ok)
+	}
+	for _, e := range v.Entries(ListCorporate) {
+		if e.Domain == "acme-labs.example" && (e.Source != "file:b" || e.CorporateDomain != "acme-group.example") {
+			t.Errorf("Entries: %+v", e)
+		}
+	}
+	if res := v.Validate("a@acme-labs.example"); res.ListSource != "file:b" || res.CorporateDomain != "acme-group.example" {
+		t.Errorf("result: list_source %q corporate_domain %q", res.ListSource, res.CorporateDomain)
+	}
+	if _, ok := v.Entry(ListDisposable, "acme-labs.example"); ok {
+		t.Error("Entry found a domain in the wrong list")
+	}
+}
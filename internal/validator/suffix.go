@@ -0,0 +1,16 @@
+package validator
+
+import "golang.org/x/net/publicsuffix"
+
+// splitRegistrable returns the public suffix of domain (co.uk, com,
+// github.io) and its registrable domain, the suffix plus one label. The
+// registrable domain is empty when domain is itself a public suffix. The
+// suffix table is the Public Suffix List compiled into x/net/publicsuffix.
+func splitRegistrable(domain string) (registrable, suffix string) {
+	suffix, _ = publicsuffix.PublicSuffix(domain)
+	registrable, err := publicsuffix.EffectiveTLDPlusOne(domain)
+	if err != nil {
+		return "", suffix
+	}
+	return registrable, suffix
+}
@@ -0,0 +1,395 @@
+package validator
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/net/idna"
+)
+
+// SyntaxMode selects how strictly addresses are parsed.
+type SyntaxMode string
+
+const (
+	// SyntaxStrict accepts what public mail systems deliver to: a dot-atom
+	// or quoted local part and a multi-label host name with an alphabetic TLD.
+	SyntaxStrict SyntaxMode = "strict"
+	// SyntaxLenient additionally accepts single-label domains, numeric TLDs
+	// and IPv4 domain literals such as user@[192.0.2.1], but not bare IPv4
+	// addresses.
+	SyntaxLenient SyntaxMode = "lenient"
+)
+
+// Length limits from RFC 5321 section 4.5.3.1.
+const (
+	maxLocalLength   = 64
+	maxDomainLength  = 253
+	maxLabelLength   = 63
+	maxAddressLength = 254
+)
+
+// Syntax error codes.
+const (
+	ErrCodeEmpty             = "EMPTY"
+	ErrCodeAddressTooLong    = "ADDRESS_TOO_LONG"
+	ErrCodeMissingAt         = "MISSING_AT"
+	ErrCodeLocalEmpty        = "LOCAL_EMPTY"
+	ErrCodeLocalTooLong      = "LOCAL_TOO_LONG"
+	ErrCodeLeadingDot        = "LEADING_DOT"
+	ErrCodeTrailingDot       = "TRAILING_DOT"
+	ErrCodeConsecutiveDots   = "CONSECUTIVE_DOTS"
+	ErrCodeInvalidChar       = "INVALID_CHARACTER"
+	ErrCodeUnterminatedQuote = "UNTERMINATED_QUOTE"
+	ErrCodeDomainEmpty       = "DOMAIN_EMPTY"
+	ErrCodeDomainTooLong     = "DOMAIN_TOO_LONG"
+	ErrCodeLabelEmpty        = "LABEL_EMPTY"
+	ErrCodeLabelTooLong      = "LABEL_TOO_LONG"
+	ErrCodeLabelHyphen       = "LABEL_HYPHEN"
+	ErrCodeSingleLabel       = "SINGLE_LABEL_DOMAIN"
+	ErrCodeInvalidTLD        = "INVALID_TLD"
+	ErrCodeDomainLiteral     = "DOMAIN_LITERAL"
+	ErrCodeInvalidUTF8       = "INVALID_UTF8"
+	ErrCodeInvalidIDN        = "INVALID_IDN"
+)
+
+// SyntaxError describes why an address failed to parse. Pos is the byte
+// offset into the input where the problem was found; inside a converted
+// IDN label it is the label's first byte.
+type SyntaxError struct {
+	Code    string `json:"code"`
+	Pos     int    `json:"position"`
+	Message string `json:"message"`
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s at position %d: %s", e.Code, e.Pos, e.Message)
+}
+
+func syntaxErr(code string, pos int, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Code: code, Pos: pos, Message: fmt.Sprintf(format, args...)}
+}
+
+// ParseAddress splits an RFC 5321 mailbox into its local part and domain.
+// UTF-8 local parts are accepted as in RFC 6531 (SMTPUTF8). The local part
+// is returned as written (including quotes); internationalized domains are
+// converted to their IDNA2008 A-label form, ASCII domains are returned as
+// written.
+func ParseAddress(addr string, mode SyntaxMode) (local, domain string, err *SyntaxError) {
+	if addr == "" {
+		return "", "", syntaxErr(ErrCodeEmpty, 0, "address is empty")
+	}
+	if !utf8.ValidString(addr) {
+		return "", "", syntaxErr(ErrCodeInvalidUTF8, invalidUTF8Pos(addr), "address is not valid UTF-8")
+	}
+	if len(addr) > maxAddressLength {
+		return "", "", syntaxErr(ErrCodeAddressTooLong, maxAddressLength, "address exceeds %d characters", maxAddressLength)
+	}
+
+	var at int
+	if addr[0] == '"' {
+		at, err = parseQuotedLocal(addr)
+	} else {
+		at, err = parseDotAtom(addr)
+	}
+	if err != nil {
+		return "", "", err
+	}
+	local = addr[:at]
+	if at > maxLocalLength {
+		return "", "", syntaxErr(ErrCodeLocalTooLong, maxLocalLength, "local part exceeds %d characters", maxLocalLength)
+	}
+
+	domain = addr[at+1:]
+	if !isASCII(domain) {
+		ascii, ierr := idna.Lookup.ToASCII(domain)
+		if ierr != nil {
+			return "", "", syntaxErr(ErrCodeInvalidIDN, at+1, "internationalized domain is not valid: %v", ierr)
+		}
+		if err := parseDomain(ascii, 0, mode); err != nil {
+			err.Pos = at + 1 + idnPos(domain, ascii, err.Pos)
+			return "", "", err
+		}
+		return local, ascii, nil
+	}
+	if err := parseDomain(domain, at+1, mode); err != nil {
+		return "", "", err
+	}
+	return local, domain, nil
+}
+
+// idnPos maps a byte offset into the A-label form of a domain back to the
+// Unicode input. Offsets inside a converted label point at its first byte;
+// unconverted labels map one to one.
+func idnPos(unicode, ascii string, pos int) int {
+	ulabels, ustarts := idnLabels(unicode)
+	alabels := strings.Split(ascii, ".")
+	if len(ulabels) != len(alabels) {
+		return 0
+	}
+	astart := 0
+	for i, alabel := range alabels {
+		ulabel, ustart := ulabels[i], ustarts[i]
+		if rel := pos - astart; rel <= len(alabel) {
+			switch {
+			case strings.EqualFold(ulabel, alabel):
+				return ustart + rel
+			case rel == len(alabel):
+				return ustart + len(ulabel)
+			default:
+				return ustart
+			}
+		}
+		astart += len(alabel) + 1
+	}
+	return len(unicode)
+}
+
+// idnLabels splits a Unicode domain at the separators IDNA maps to '.' and
+// returns the labels with their byte offsets.
+func idnLabels(domain string) (labels []string, starts []int) {
+	start := 0
+	for i, r := range domain {
+		if r == '.' || r == '\u3002' || r == '\uff0e' || r == '\uff61' {
+			labels = append(labels, domain[start:i])
+			starts = append(starts, start)
+			start = i + utf8.RuneLen(r)
+		}
+	}
+	return append(labels, domain[start:]), append(starts, start)
+}
+
+// NormalizeDomain checks a bare domain name as it would appear after the @
+// of an address and returns its lowercase A-label form, as used by the
+// domain lists.
+func NormalizeDomain(domain string) (string, error) {
+	domain = strings.TrimSuffix(strings.TrimSpace(domain), ".")
+	if !utf8.ValidString(domain) {
+		return "", syntaxErr(ErrCodeInvalidUTF8, invalidUTF8Pos(domain), "domain is not valid UTF-8")
+	}
+	ascii := domain
+	if !isASCII(domain) {
+		var err error
+		if ascii, err = idna.Lookup.ToASCII(domain); err != nil {
+			return "", syntaxErr(ErrCodeInvalidIDN, 0, "internationalized domain is not valid: %v", err)
+		}
+	}
+	if err := parseDomain(ascii, 0, SyntaxStrict); err != nil {
+		err.Pos = idnPos(domain, ascii, err.Pos)
+		return "", err
+	}
+	return strings.ToLower(ascii), nil
+}
+
+// parseDotAtom scans an unquoted local part and returns the index of '@'.
+func parseDotAtom(addr string) (int, *SyntaxError) {
+	for i := 0; i < len(addr); i++ {
+		c := addr[i]
+		switch {
+		case c == '@':
+			if i == 0 {
+				return 0, syntaxErr(ErrCodeLocalEmpty, 0, "local part is empty")
+			}
+			if addr[i-1] == '.' {
+				return 0, syntaxErr(ErrCodeTrailingDot, i-1, "local part ends with a dot")
+			}
+			return i, nil
+		case c == '.':
+			if i == 0 {
+				return 0, syntaxErr(ErrCodeLeadingDot, 0, "local part starts with a dot")
+			}
+			if addr[i-1] == '.' {
+				return 0, syntaxErr(ErrCodeConsecutiveDots, i, "consecutive dots in local part")
+			}
+		case c >= utf8.RuneSelf:
+			size, err := localRune(addr, i)
+			if err != nil {
+				return 0, err
+			}
+			i += size - 1
+		case isAtext(c):
+		default:
+			return 0, syntaxErr(ErrCodeInvalidChar, i, "character %q is not allowed in an unquoted local part", c)
+		}
+	}
+	return 0, syntaxErr(ErrCodeMissingAt, len(addr), "missing @")
+}
+
+// localRune checks the non-ASCII character at addr[i] of a local part and
+// returns its size. RFC 6531 allows any UTF-8 beyond ASCII, but control and
+// format characters (C1 controls, zero-width joiners, bidi overrides) are
+// invisible or reorder the text, so no real mailbox uses them.
+func localRune(addr string, i int) (int, *SyntaxError) {
+	r, size := utf8.DecodeRuneInString(addr[i:])
+	if unicode.In(r, unicode.Cc, unicode.Cf) {
+		return 0, syntaxErr(ErrCodeInvalidChar, i, "character %U is not allowed in a local part", r)
+	}
+	return size, nil
+}
+
+// parseQuotedLocal scans a quoted-string local part and returns the index of
+// the '@' that follows the closing quote.
+func parseQuotedLocal(addr string) (int, *SyntaxError) {
+	for i := 1; i < len(addr); i++ {
+		c := addr[i]
+		switch {
+		case c == '\\':
+			i++
+			if i >= len(addr) || addr[i] < 0x20 || addr[i] > 0x7e {
+				return 0, syntaxErr(ErrCodeInvalidChar, i, "invalid quoted pair")
+			}
+		case c == '"':
+			if i == 1 {
+				return 0, syntaxErr(ErrCodeLocalEmpty, 0, "local part is empty")
+			}
+			if i+1 >= len(addr) || addr[i+1] != '@' {
+				return 0, syntaxErr(ErrCodeMissingAt, i+1, "expected @ after quoted local part")
+			}
+			return i + 1, nil
+		case c < 0x20 || c == 0x7f:
+			return 0, syntaxErr(ErrCodeInvalidChar, i, "character %q is not allowed in a quoted local part", c)
+		case c >= utf8.RuneSelf:
+			size, err := localRune(addr, i)
+			if err != nil {
+				return 0, err
+			}
+			i += size - 1
+		}
+	}
+	return 0, syntaxErr(ErrCodeUnterminatedQuote, len(addr), "quoted local part is not terminated")
+}
+
+// parseDomain checks a domain that starts at offset within the address.
+func parseDomain(domain string, offset int, mode SyntaxMode) *SyntaxError {
+	if domain == "" {
+		return syntaxErr(ErrCodeDomainEmpty, offset, "domain is empty")
+	}
+	if domain[0] == '[' {
+		if mode != SyntaxLenient {
+			return syntaxErr(ErrCodeDomainLiteral, offset, "domain literals are only accepted in lenient mode")
+		}
+		if !strings.HasSuffix(domain, "]") || !isIPv4(domain[1:len(domain)-1]) {
+			return syntaxErr(ErrCodeDomainLiteral, offset, "invalid domain literal")
+		}
+		return nil
+	}
+	if len(domain) > maxDomainLength {
+		return syntaxErr(ErrCodeDomainTooLong, offset+maxDomainLength, "domain exceeds %d characters", maxDomainLength)
+	}
+
+	labels := strings.Split(domain, ".")
+	pos := offset
+	for i, label := range labels {
+		switch {
+		case label == "" && i == 0:
+			return syntaxErr(ErrCodeLeadingDot, pos, "domain starts with a dot")
+		case label == "" && i == len(labels)-1:
+			return syntaxErr(ErrCodeTrailingDot, pos-1, "domain ends with a dot")
+		case label == "":
+			return syntaxErr(ErrCodeConsecutiveDots, pos, "consecutive dots in domain")
+		case len(label) > maxLabelLength:
+			return syntaxErr(ErrCodeLabelTooLong, pos, "domain label exceeds %d characters", maxLabelLength)
+		case label[0] == '-':
+			return syntaxErr(ErrCodeLabelHyphen, pos, "domain label starts with a hyphen")
+		case label[len(label)-1] == '-':
+			return syntaxErr(ErrCodeLabelHyphen, pos+len(label)-1, "domain label ends with a hyphen")
+		}
+		for j := 0; j < len(label); j++ {
+			if !isLabelChar(label[j]) {
+				return syntaxErr(ErrCodeInvalidChar, pos+j, "character %q is not allowed in a domain", label[j])
+			}
+		}
+		pos += len(label) + 1
+	}
+
+	// An IPv4 address is not a host name, even where numeric TLDs are
+	// accepted
+	if isIPv4(domain) {
+		return syntaxErr(ErrCodeDomainLiteral, offset, "IPv4 addresses must be written as a domain literal, e.g. [%s]", domain)
+	}
+	if mode == SyntaxLenient {
+		return nil
+	}
+	if len(labels) < 2 {
+		return syntaxErr(ErrCodeSingleLabel, offset, "domain needs at least two labels")
+	}
+	tld := labels[len(labels)-1]
+	if !isValidTLD(tld) {
+		return syntaxErr(ErrCodeInvalidTLD, offset+len(domain)-len(tld), "top-level domain %q is not valid", tld)
+	}
+	return nil
+}
+
+// isAtext reports whether c is an RFC 5322 atext character.
+func isAtext(c byte) bool {
+	if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' {
+		return true
+	}
+	return strings.IndexByte("!#$%&'*+-/=?^_`{|}~", c) >= 0
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
+
+func invalidUTF8Pos(s string) int {
+	for i := 0; i < len(s); {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if r == utf8.RuneError && size <= 1 {
+			return i
+		}
+		i += size
+	}
+	return len(s)
+}
+
+func isLabelChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-'
+}
+
+// isValidTLD accepts alphabetic TLDs of two or more letters and IDNA
+// A-labels.
+func isValidTLD(tld string) bool {
+	if len(tld) >= 4 && strings.EqualFold(tld[:4], "xn--") {
+		return true
+	}
+	if len(tld) < 2 {
+		return false
+	}
+	for i := 0; i < len(tld); i++ {
+		c := tld[i]
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+func isIPv4(s string) bool {
+	parts := strings.Split(s, ".")
+	if len(parts) != 4 {
+		return false
+	}
+	for _, p := range parts {
+		if p == "" || len(p) > 3 {
+			return false
+		}
+		n := 0
+		for i := 0; i < len(p); i++ {
+			if p[i] < '0' || p[i] > '9' {
+				return false
+			}
+			n = n*10 + int(p[i]-'0')
+		}
+		if n > 255 {
+			return false
+		}
+	}
+	return true
+}
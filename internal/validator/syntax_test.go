@@ -0,0 +1,108 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		addr string
+		mode SyntaxMode
+		code string
+		pos  int
+	}{
+		{"john.doe@example.com", SyntaxStrict, "", 0},
+		{"o'brien@example.ie", SyntaxStrict, "", 0},
+		{"user+tag@sub.example.co.uk", SyntaxStrict, "", 0},
+		{`"john doe"@example.com`, SyntaxStrict, "", 0},
+		{`"a\"b@c"@example.com`, SyntaxStrict, "", 0},
+		{"x@xn--e1afmkfd.xn--p1ai", SyntaxStrict, "", 0},
+		{"x@пример.рф", SyntaxStrict, "", 0},
+		{"hans@bücher.de", SyntaxStrict, "", 0},
+		{"用户@例子.广告", SyntaxStrict, "", 0},
+		{"δοκιμή@παράδειγμα.δοκιμή", SyntaxStrict, "", 0},
+		{"\"δοκιμή\"@example.com", SyntaxStrict, "", 0},
+		{"admin@localhost", SyntaxLenient, "", 0},
+		{"admin@[192.0.2.1]", SyntaxLenient, "", 0},
+
+		{"", SyntaxStrict, ErrCodeEmpty, 0},
+		{"john.example.com", SyntaxStrict, ErrCodeMissingAt, 16},
+		{"@example.com", SyntaxStrict, ErrCodeLocalEmpty, 0},
+		{".john@example.com", SyntaxStrict, ErrCodeLeadingDot, 0},
+		{"john.@example.com", SyntaxStrict, ErrCodeTrailingDot, 4},
+		{"john..doe@example.com", SyntaxStrict, ErrCodeConsecutiveDots, 5},
+		{"john doe@example.com", SyntaxStrict, ErrCodeInvalidChar, 4},
+		{"a@b@example.com", SyntaxStrict, ErrCodeInvalidChar, 3},
+		{`"john@example.com`, SyntaxStrict, ErrCodeUnterminatedQuote, 17},
+		{strings.Repeat("a", 65) + "@example.com", SyntaxStrict, ErrCodeLocalTooLong, 64},
+		{"john@", SyntaxStrict, ErrCodeDomainEmpty, 5},
+		{"john@.example.com", SyntaxStrict, ErrCodeLeadingDot, 5},
+		{"john@example.com.", SyntaxStrict, ErrCodeTrailingDot, 16},
+		{"john@example..com", SyntaxStrict, ErrCodeConsecutiveDots, 13},
+		{"john@-example.com", SyntaxStrict, ErrCodeLabelHyphen, 5},
+		{"john@example-.com", SyntaxStrict, ErrCodeLabelHyphen, 12},
+		{"john@" + strings.Repeat("a", 64) + ".com", SyntaxStrict, ErrCodeLabelTooLong, 5},
+		{"john@exa_mple.com", SyntaxStrict, ErrCodeInvalidChar, 8},
+		{"admin@localhost", SyntaxStrict, ErrCodeSingleLabel, 6},
+		{"john@example.c", SyntaxStrict, ErrCodeInvalidTLD, 13},
+		{"john@example.123", SyntaxStrict, ErrCodeInvalidTLD, 13},
+		{"admin@[192.0.2.1]", SyntaxStrict, ErrCodeDomainLiteral, 6},
+		{"a\xffb@example.com", SyntaxStrict, ErrCodeInvalidUTF8, 1},
+		{"jo\u200bhn@example.com", SyntaxStrict, ErrCodeInvalidChar, 2},
+		{"\u202egnp.exe@example.com", SyntaxStrict, ErrCodeInvalidChar, 0},
+		{"a\u0085b@example.com", SyntaxStrict, ErrCodeInvalidChar, 1},
+		{"\"a\u200db\"@example.com", SyntaxStrict, ErrCodeInvalidChar, 2},
+		{"x@bü cher.de", SyntaxStrict, ErrCodeInvalidIDN, 2},
+		{"x@bücher.c", SyntaxStrict, ErrCodeInvalidTLD, 10},
+		{"x@bücher..de", SyntaxStrict, ErrCodeConsecutiveDots, 10},
+		{"x@bücher.de.", SyntaxStrict, ErrCodeTrailingDot, 12},
+		{"x@bücher\u3002c", SyntaxStrict, ErrCodeInvalidTLD, 12},
+		{"admin@192.0.2.1", SyntaxLenient, ErrCodeDomainLiteral, 6},
+		{"admin@127.0.0.1", SyntaxStrict, ErrCodeDomainLiteral, 6},
+		{"a@" + strings.Repeat("abcdefghi.", 26) + "com", SyntaxLenient, ErrCodeAddressTooLong, 254},
+	}
+	for _, tt := range tests {
+		_, _, err := ParseAddress(tt.addr, tt.mode)
+		switch {
+		case tt.code == "" && err != nil:
+			t.Errorf("ParseAddress(%q, %s) = %v, want ok", tt.addr, tt.mode, err)
+		case tt.code != "" && err == nil:
+			t.Errorf("ParseAddress(%q, %s) succeeded, want %s", tt.addr, tt.mode, tt.code)
+		case tt.code != "" && (err.Code != tt.code || err.Pos != tt.pos):
+			t.Errorf("ParseAddress(%q, %s) = %s at %d, want %s at %d", tt.addr, tt.mode, err.Code, err.Pos, tt.code, tt.pos)
+		}
+	}
+}
+
+func TestParseAddressIDN(t *testing.T) {
+	tests := []struct{ addr, local, domain string }{
+		{"x@пример.рф", "x", "xn--e1afmkfd.xn--p1ai"},
+		{"hans@Bücher.de", "hans", "xn--bcher-kva.de"},
+		{"Ünsal@example.com", "Ünsal", "example.com"},
+	}
+	for _, tt := range tests {
+		local, domain, err := ParseAddress(tt.addr, SyntaxStrict)
+		if err != nil || local != tt.local || domain != tt.domain {
+			t.Errorf("ParseAddress(%q) = %q, %q, %v; want %q, %q", tt.addr, local, domain, err, tt.local, tt.domain)
+		}
+	}
+}
+
+func TestNormalizeDomain(t *testing.T) {
+	tests := []struct{ in, want string }{
+		{" Acme.Example. ", "acme.example"},
+		{"пример.рф", "xn--e1afmkfd.xn--p1ai"},
+		{"", ""},
+		{"acme", ""},
+		{"a@acme.example", ""},
+		{"-acme.example", ""},
+		{"[192.0.2.1]", ""},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeDomain(tt.in)
+		if got != tt.want || (err == nil) != (tt.want != "") {
+			t.Errorf("NormalizeDomain(%q) = %q, %v; want %q", tt.in, got, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package validator
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TraceStep is one entry of a debug trace. Pipeline stages are syntax,
+// role, classify, company, homograph, suggestion, mx, fingerprint,
+// mail_auth, smtp, disposable_heuristic, mx_heuristic, verdict and score;
+// every DNS query made on the way is recorded as a dns step when it
+// completes. The API adds an ai step, and a fresh score, after an AI check.
+type TraceStep struct {
+	Stage      string   `json:"stage"`
+	Input      string   `json:"input,omitempty"`
+	Rule       string   `json:"rule,omitempty"`
+	ListSource string   `json:"list_source,omitempty"`
+	Answers    []string `json:"answers,omitempty"`
+	Outcome    string   `json:"outcome"`
+	DurationMS float64  `json:"duration_ms"`
+}
+
+// trace collects the steps of one validation. A nil trace records nothing,
+// so the pipeline calls it unconditionally.
+type trace struct {
+	mu    sync.Mutex
+	steps []TraceStep
+}
+
+func newTrace(enabled bool) *trace {
+	if !enabled {
+		return nil
+	}
+	return &trace{steps: []TraceStep{}}
+}
+
+// add records a step that began at start.
+func (t *trace) add(start time.Time, s TraceStep) {
+	if t == nil {
+		return
+	}
+	s.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+	t.mu.Lock()
+	t.steps = append(t.steps, s)
+	t.mu.Unlock()
+}
+
+func (t *trace) result() []TraceStep {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.steps
+}
+
+// AddTraceStep records a step that began at start, for callers that change
+// a result after validation. Results without a trace are left alone.
+func (r *ValidationResult) AddTraceStep(start time.Time, s TraceStep) {
+	if r.Trace == nil {
+		return
+	}
+	s.DurationMS = float64(time.Since(start).Microseconds()) / 1000
+	r.Trace = append(r.Trace, s)
+}
+
+// tracingResolver records every query and its answers.
+type tracingResolver struct {
+	next  Resolver
+	trace *trace
+}
+
+func (r *tracingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	start := time.Now()
+	recs, err := r.next.LookupMX(ctx, name)
+	answers := make([]string, len(recs))
+	for i, mx := range recs {
+		answers[i] = strconv.Itoa(int(mx.Pref)) + " " + mx.Host
+	}
+	r.record(start, "MX", name, answers, err)
+	return recs, err
+}
+
+func (r *tracingResolver) LookupHost(ctx context.Context, name string) ([]string, error) {
+	start := time.Now()
+	addrs, err := r.next.LookupHost(ctx, name)
+	r.record(start, "A/AAAA", name, addrs, err)
+	return addrs, err
+}
+
+func (r *tracingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	start := time.Now()
+	txts, err := r.next.LookupTXT(ctx, name)
+	r.record(start, "TXT", name, txts, err)
+	return txts, err
+}
+
+func (r *tracingResolver) LookupNS(ctx context.Context, name string) ([]*net.NS, error) {
+	start := time.Now()
+	recs, err := lookupNS(ctx, r.next, name)
+	answers := make([]string, len(recs))
+	for i, ns := range recs {
+		answers[i] = ns.Host
+	}
+	r.record(start, "NS", name, answers, err)
+	return recs, err
+}
+
+func (r *tracingResolver) record(start time.Time, qtype, name string, answers []string, err error) {
+	outcome := "answered"
+	switch {
+	case err == nil && len(answers) == 0:
+		outcome = "empty answer"
+	case isNotFound(err):
+		outcome = "not found"
+	case isTimeout(err):
+		outcome = "timed out"
+	case err != nil:
+		outcome = "error: " + err.Error()
+	}
+	r.trace.add(start, TraceStep{Stage: "dns", Input: qtype + " " + name, Answers: answers, Outcome: outcome})
+}
@@ -0,0 +1,61 @@
+package validator
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDebugTrace(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Resolver = NewFakeResolver().
+		AddMX("acme.example", "mx.acme.example.", 10).
+		AddHost("mx.acme.example", "64.233.184.26")
+	v := New(opts)
+	ctx := context.Background()
+
+	plain := v.Check(ctx, "jane@acme.example", CheckOptions{})
+	if plain.Trace != nil {
+		t.Fatalf("trace without debug: %+v", plain.Trace)
+	}
+	if b, _ := json.Marshal(plain); strings.Contains(string(b), `"trace"`) {
+		t.Error("trace key present in a normal response")
+	}
+
+	debug := v.Check(ctx, "jane@acme.example", CheckOptions{Debug: true})
+	debug.Trace, plain.Trace = nil, nil
+	a, _ := json.Marshal(plain)
+	b, _ := json.Marshal(debug)
+	if string(a) != string(b) {
+		t.Errorf("debug changed the result:\n%s\n%s", a, b)
+	}
+
+	tests := []struct {
+		email  string
+		stages []string
+	}{
+		{"jane@acme.example", []string{"syntax", "role", "classify", "suggestion", "dns", "dns", "mx", "dns", "fingerprint", "disposable_heuristic", "mx_heuristic", "verdict", "score"}},
+		{"eve@yopmail.com", []string{"syntax", "role", "classify", "verdict", "score"}},
+		{"bad@@example.com", []string{"syntax", "verdict", "score"}},
+	}
+	for _, tt := range tests {
+		r := v.Check(ctx, tt.email, CheckOptions{Debug: true})
+		var stages []string
+		for _, s := range r.Trace {
+			stages = append(stages, s.Stage)
+		}
+		if strings.Join(stages, ",") != strings.Join(tt.stages, ",") {
+			t.Errorf("%s: stages %v, want %v", tt.email, stages, tt.stages)
+		}
+	}
+
+	r := v.Check(ctx, "eve@yopmail.com", CheckOptions{Debug: true})
+	if s := r.Trace[2]; s.Rule != RuleDisposableList || s.ListSource != SourceBuiltin {
+		t.Errorf("classify step = %+v", s)
+	}
+	r = v.Check(ctx, "jane@acme.example", CheckOptions{Debug: true})
+	if s := r.Trace[4]; s.Input != "MX acme.example" || len(s.Answers) != 1 || s.Answers[0] != "10 mx.acme.example." {
+		t.Errorf("dns step = %+v", s)
+	}
+}
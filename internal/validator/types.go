@@ -1,22 +1,89 @@
 package validator
 
 type ValidationResult struct {
-	Email           string `json:"email"`
-	Valid           bool   `json:"valid"`
-	SyntaxValid     bool   `json:"syntax_valid"`
-	DomainValid     bool   `json:"domain_valid"`
-	MXRecordsFound  bool   `json:"mx_records_found"`
-	ProviderName    string `json:"provider_name"`
-	ProviderType    string `json:"provider_type"` // "personal", "corporate", "disposable"
-	IsDisposable    bool   `json:"is_disposable"`
-	IsCorporate     bool   `json:"is_corporate"`
-	IsPersonal      bool   `json:"is_personal"`
-	CorporateDomain string `json:"corporate_domain,omitempty"`
-	Message         string `json:"message"`
+	Email                string       `json:"email"`
+	Valid                bool         `json:"valid"`
+	SyntaxValid          bool         `json:"syntax_valid"`
+	SyntaxError          *SyntaxError `json:"syntax_error,omitempty"`
+	CanonicalEmail       string       `json:"canonical_email,omitempty"`    // provider-normalized form for deduplication
+	DomainASCII          string       `json:"domain_ascii,omitempty"`       // IDNA A-label (punycode) form used for lookups
+	DomainUnicode        string       `json:"domain_unicode,omitempty"`     // U-label form for display
+	SMTPUTF8             bool         `json:"smtputf8"`                     // local part needs an SMTPUTF8-capable server
+	MixedScript          bool         `json:"mixed_script,omitempty"`       // a domain label mixes scripts, e.g. Latin and Cyrillic
+	HomographOf          string       `json:"homograph_of,omitempty"`       // known domain this IDN visually imitates
+	RegistrableDomain    string       `json:"registrable_domain,omitempty"` // eTLD+1 per the Public Suffix List
+	PublicSuffix         string       `json:"public_suffix,omitempty"`
+	DomainValid          bool         `json:"domain_valid"`
+	MXRecordsFound       bool         `json:"mx_records_found"`
+	NullMX               bool         `json:"null_mx,omitempty"`  // domain publishes a null MX and accepts no email (RFC 7505)
+	MXHosts              []MXHost     `json:"mx_hosts,omitempty"` // per-target diagnostics in preference order
+	ProviderName         string       `json:"provider_name"`
+	ProviderType         string       `json:"provider_type"`              // "personal", "corporate", "disposable"
+	HostingProvider      string       `json:"hosting_provider,omitempty"` // platform storing the domain's mailboxes, e.g. Google Workspace
+	Gateway              string       `json:"gateway,omitempty"`          // security gateway in front of the mailboxes, e.g. Proofpoint
+	IsDisposable         bool         `json:"is_disposable"`
+	DisposableConfidence float64      `json:"disposable_confidence"`        // 1 for a list match, else the heuristic confidence
+	DisposableReason     string       `json:"disposable_reason,omitempty"`  // evidence behind disposable_confidence
+	DisposableSignals    []string     `json:"disposable_signals,omitempty"` // heuristic signals, see Signal* constants
+	IsCorporate          bool         `json:"is_corporate"`
+	IsPersonal           bool         `json:"is_personal"`
+	CorporateDomain      string       `json:"corporate_domain,omitempty"`
+	CompanyID            string       `json:"company_id,omitempty"`
+	ParentCompanyID      string       `json:"parent_company_id,omitempty"`
+	ClassifiedBy         string       `json:"classified_by,omitempty"` // rule that decided provider_type, see Rule* constants
+	Confidence           float64      `json:"confidence"`              // confidence in provider_type, 0 when unclassified
+	ListSource           string       `json:"list_source,omitempty"`   // list source that matched: builtin, companies, file:<path> or a URL
+	TimedOut             []string     `json:"timed_out,omitempty"`     // stages that hit their deadline, see Stage* constants
+	MailAuth             *MailAuth    `json:"mail_auth,omitempty"`
+	SMTP                 *SMTPCheck   `json:"smtp,omitempty"`
+	IsCatchAll           bool         `json:"is_catch_all"`
+	IsRole               bool         `json:"is_role"`
+	RoleCategory         string       `json:"role_category,omitempty"`
+	Suggestion           string       `json:"suggestion,omitempty"` // "did you mean" address for a likely domain typo
+	RiskScore            int          `json:"risk_score"`           // 0 (no concerns) to 100, the capped sum of the reasons' weights
+	Reasons              []string     `json:"reasons"`              // reason codes, heaviest first; see Reason* constants
+	Message              string       `json:"message"`
+	Trace                []TraceStep  `json:"trace,omitempty"` // pipeline steps, only with CheckOptions.Debug
+}
+
+// CheckOptions are per-request switches for the validation pipeline.
+type CheckOptions struct {
+	RejectRole bool       // report role accounts such as info@ or sales@ as invalid
+	Syntax     SyntaxMode // defaults to SyntaxStrict
+	Debug      bool       // record a trace of every pipeline step in the result
+}
+
+// Classification rules in order of precedence.
+const (
+	RuleOverrideCorporate   = "override_corporate"
+	RuleOverridePersonal    = "override_personal"
+	RuleDisposableList      = "disposable_list"
+	RuleCorporateMap        = "corporate_map"
+	RuleFreeList            = "free_list"
+	RuleDisposableHeuristic = "disposable_heuristic"
+	RuleMXHeuristic         = "mx_heuristic"
+	RuleAI                  = "ai" // set by the API when an AI verdict replaces the rule's
+)
+
+// Pipeline stages with individual time budgets.
+const (
+	StageDNS  = "dns"
+	StageSMTP = "smtp"
+	StageAI   = "ai"
+)
+
+// SMTPCheck is the outcome of the RCPT TO mailbox probe.
+type SMTPCheck struct {
+	Status     string  `json:"status"` // "deliverable", "undeliverable", "unknown"
+	Code       int     `json:"code,omitempty"`
+	Message    string  `json:"message,omitempty"`
+	MXHost     string  `json:"mx_host,omitempty"`
+	CatchAll   bool    `json:"catch_all"`
+	Confidence float64 `json:"confidence"` // how much the status can be trusted, 0-1
 }
 
 type ProviderInfo struct {
-	Name        string `json:"name"`
-	Type        string `json:"type"` // "personal", "corporate", "disposable"
+	Name            string `json:"name"`
+	Type            string `json:"type"` // "personal", "corporate", "disposable"
 	CorporateDomain string `json:"corporate_domain,omitempty"`
-}
\ No newline at end of file
+}
@@ -0,0 +1,171 @@
+package validator
+
+import (
+	"net"
+	"strings"
+)
+
+// tldTypos maps common misspellings of popular TLDs. None of the keys is a
+// delegated TLD, so correcting them is always safe.
+var tldTypos = map[string]string{
+	"con": "com", "cmo": "com", "ocm": "com", "vom": "com", "xom": "com", "comm": "com",
+	"coom": "com", "copm": "com", "cpm": "com", "cim": "com", "clm": "com", "coim": "com",
+	"nte": "net", "ner": "net", "nwt": "net", "nett": "net",
+	"ogr": "org", "rog": "org", "orgg": "org", "prg": "org",
+	"rru": "ru", "ruu": "ru",
+}
+
+// keyboardRows is the QWERTY layout used to discount substitutions of
+// neighbouring keys.
+var keyboardRows = []string{"1234567890-", "qwertyuiop", "asdfghjkl", "zxcvbnm"}
+
+var keyPositions = func() map[rune][2]int {
+	pos := make(map[rune][2]int)
+	for row, keys := range keyboardRows {
+		for col, k := range keys {
+			pos[k] = [2]int{row, col}
+		}
+	}
+	return pos
+}()
+
+// suggestDomain returns the most likely intended domain for a mistyped one,
+// or "" when nothing is close enough. Popular domains (personal and
+// corporate lists) are matched more generously than the long free list.
+//
+// Companies own plenty of real domains one edit away from their main one
+// (apple.co, netflix.co), and the free list is long enough to sit one edit
+// away from real company domains, so a corporate or free list suggestion
+// is only a guess: unconfirmed is set and the caller checks it against the
+// domain's MX with mxOwnedBy.
+func (d *dataset) suggestDomain(domain string) (suggestion string, unconfirmed bool) {
+	if i := strings.LastIndexByte(domain, '.'); i > 0 {
+		if fixed, ok := tldTypos[domain[i+1:]]; ok {
+			// The mistyped TLD does not exist, so any match is safe
+			candidate := domain[:i+1] + fixed
+			if d.known(candidate) {
+				return candidate, false
+			}
+			if best, _ := d.closestDomain(candidate); best != "" {
+				return best, false
+			}
+			return candidate, false
+		}
+	}
+	return d.closestDomain(domain)
+}
+
+// closestDomain returns the nearest known domain and whether it is only a
+// guess, i.e. came from the corporate list (which only matches within one
+// edit) or the free list.
+func (d *dataset) closestDomain(domain string) (string, bool) {
+	best, bestDist, bestGuess := "", 0.0, false
+	consider := func(candidate string, maxDist float64, guess bool) {
+		if candidate == domain || abs(len(candidate)-len(domain)) > 2 {
+			return
+		}
+		dist := typoDistance(domain, candidate)
+		if dist == 0 || dist > maxDist {
+			return
+		}
+		if best == "" || dist < bestDist || (dist == bestDist && candidate < best) {
+			best, bestDist, bestGuess = candidate, dist, guess
+		}
+	}
+
+	// Short domains are too close to each other to guess safely
+	popularMax, freeMax := 1.0, 1.0
+	switch {
+	case len(domain) < 8:
+		popularMax, freeMax = 0.5, 0
+	case len(domain) >= 10:
+		popularMax = 2.0
+	}
+	for candidate := range d.personal {
+		consider(candidate, popularMax, false)
+	}
+	for candidate := range d.corporate {
+		consider(candidate, min(popularMax, 1), true)
+	}
+	if best != "" {
+		return best, bestGuess
+	}
+	for candidate := range d.free {
+		consider(candidate, freeMax, true)
+	}
+	return best, best != ""
+}
+
+// mxOwnedBy reports whether every MX target of a domain lies under the
+// registrable domain of suggestion, meaning the suggested domain's owner
+// also runs the typed domain's mail. A domain with its own mail hosts is
+// a real domain rather than a typo.
+func mxOwnedBy(mxRecords []*net.MX, suggestion string) bool {
+	owner, _ := splitRegistrable(suggestion)
+	if owner == "" {
+		owner = suggestion
+	}
+	for _, mx := range mxRecords {
+		if !matchLabels(owner, mxTarget(mx)) {
+			return false
+		}
+	}
+	return true
+}
+
+// typoDistance is an optimal string alignment distance where substituting
+// a neighbouring key costs 0.5 and adjacent transpositions cost 1.
+func typoDistance(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	rows := make([][]float64, len(ra)+1)
+	for i := range rows {
+		rows[i] = make([]float64, len(rb)+1)
+		rows[i][0] = float64(i)
+	}
+	for j := range rows[0] {
+		rows[0][j] = float64(j)
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			sub := 0.0
+			if ra[i-1] != rb[j-1] {
+				sub = 1
+				if adjacentKeys(ra[i-1], rb[j-1]) {
+					sub = 0.5
+				}
+			}
+			cost := minFloat(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+sub)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cost = minFloat(cost, rows[i-2][j-2]+1)
+			}
+			rows[i][j] = cost
+		}
+	}
+	return rows[len(ra)][len(rb)]
+}
+
+func adjacentKeys(a, b rune) bool {
+	pa, okA := keyPositions[a]
+	pb, okB := keyPositions[b]
+	if !okA || !okB {
+		return false
+	}
+	return abs(pa[0]-pb[0]) <= 1 && abs(pa[1]-pb[1]) <= 1
+}
+
+func minFloat(values ...float64) float64 {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
@@ -1,130 +1,191 @@
 package validator
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+	"errors"
 	"net"
-	"net/http"
-	"regexp"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
-)
 
-var (
-	emailRegex = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
-
-	// Corporate domain mappings -- official employee email domains
-	corporateDomains = map[string]string{
-		"google.com":     "google.com",
-		"microsoft.com":  "microsoft.com",
-		"apple.com":      "apple.com",
-		"meta.com":       "meta.com",
-		"facebook.com":   "meta.com",
-		"instagram.com":  "meta.com",
-		"whatsapp.com":   "meta.com",
-		"amazon.com":     "amazon.com",
-		"bytedance.com":  "bytedance.com",
-		"tiktok.com":     "bytedance.com",
-		"spotify.com":    "spotify.com",
-		"netflix.com":    "netflix.com",
-		"adobe.com":      "adobe.com",
-		"salesforce.com": "salesforce.com",
-		"slack.com":      "slack.com",
-		"zoom.us":        "zoom.us",
-		"dropbox.com":    "dropbox.com",
-		"github.com":     "github.com",
-		"linkedin.com":   "linkedin.com",
-		"twitter.com":    "twitter.com",
-		"x.com":          "twitter.com",
-	}
-
-	// Known disposable email domains (simplified list)
-	disposableDomains = map[string]bool{
-		"mailinator.com":    true,
-		"guerrillamail.com": true,
-		"10minutemail.com":  true,
-		"tempmail.org":      true,
-		"yopmail.com":       true,
-		"mailnesia.com":     true,
-		"tempmailo.com":     true,
-		"throwaway.email":   true,
-		"trashmail.com":     true,
-		"mailcatch.com":     true,
-		"dispostable.com":   true,
-		"maildrop.cc":       true,
-		"fakeinbox.com":     true,
-		"mailforspam.com":   true,
-		"mintemail.com":     true,
-		"sharklasers.com":   true,
-		"spam4.me":          true,
-		"tempinbox.com":     true,
-		"trbvm.com":         true,
-	}
-
-	freeProviders        map[string]bool
-	knownPersonalDomains = map[string]bool{
-		"gmail.com":      true,
-		"yahoo.com":      true,
-		"outlook.com":    true,
-		"hotmail.com":    true,
-		"live.com":       true,
-		"icloud.com":     true,
-		"yandex.ru":      true,
-		"yandex.com":     true,
-		"ya.ru":          true,
-		"ya.com":         true,
-		"mail.ru":        true,
-		"protonmail.com": true,
-		"pm.me":          true,
-		"zoho.com":       true,
-	}
-
-	overrideCorporate map[string]bool
-	overridePersonal  map[string]bool
+	"golang.org/x/net/idna"
 )
 
-func init() {
-	freeProviders = make(map[string]bool)
-	overrideCorporate = make(map[string]bool)
-	overridePersonal = make(map[string]bool)
+// Options configures a Validator. The lists are used exactly as given; start
+// from DefaultOptions to extend the built-in data.
+type Options struct {
+	Companies          []Company
+	Fingerprints       []Fingerprint     // mail hosting and gateway detection
+	CorporateDomains   map[string]string // extra domain -> canonical corporate domain entries
+	DisposableDomains  []string
+	DisposableMX       []string // mail host patterns of disposable services, e.g. "*.mailhost.example"
+	DisposableNS       []string // nameserver patterns of disposable services
+	PersonalDomains    []string
+	FreeProviders      []string
+	CorporateOverrides []string
+	PersonalOverrides  []string
+	Lists              []DomainList        // extra sources merged into the lists above
+	RoleAccounts       map[string]string   // local-part pattern -> role category
+	Normalization      []NormalizationRule // provider rules for canonical_email
+	RiskWeights        map[string]int      // reason code -> risk score weight
+	Resolver           Resolver            // defaults to the system resolver
+	Timeouts           Timeouts
+	SMTP               *SMTPProber      // optional mailbox probe, nil disables it
+	MailAuth           *MailAuthOptions // SPF/DMARC/DKIM lookups, nil disables them
+	AllowPrivateMX     bool             // accept MX targets on private, loopback and reserved addresses
 }
 
-func SetOverrides(corporate []string, personal []string) {
-	for k := range overrideCorporate {
-		delete(overrideCorporate, k)
-	}
-	for k := range overridePersonal {
-		delete(overridePersonal, k)
-	}
-	for _, d := range corporate {
-		overrideCorporate[strings.ToLower(d)] = true
+// Timeouts bounds the individual pipeline stages. Zero means the stage is
+// limited only by the caller's context.
+type Timeouts struct {
+	DNS  time.Duration
+	SMTP time.Duration
+}
+
+// DefaultOptions returns the built-in domain lists without overrides.
+func DefaultOptions() Options {
+	return Options{
+		Companies:         DefaultCompanies(),
+		Fingerprints:      DefaultFingerprints(),
+		CorporateDomains:  make(map[string]string),
+		DisposableDomains: setKeys(defaultDisposableDomains),
+		PersonalDomains:   setKeys(defaultPersonalDomains),
+		RoleAccounts:      DefaultRoleAccounts(),
+		Normalization:     DefaultNormalizationRules(),
+		RiskWeights:       DefaultRiskWeights(),
 	}
-	for _, d := range personal {
-		overridePersonal[strings.ToLower(d)] = true
+}
+
+// Validator classifies email addresses against its own dataset. It is safe
+// for concurrent use; list updates are applied by atomically swapping the
+// whole dataset.
+type Validator struct {
+	resolver Resolver
+	timeouts Timeouts
+	smtp     *SMTPProber
+	mailAuth *MailAuthOptions
+	// allowPrivateMX lets intranet deployments validate domains whose mail
+	// hosts are only reachable internally
+	allowPrivateMX bool
+	data           atomic.Pointer[dataset]
+	mu             sync.Mutex // serialises dataset writers
+
+	learnMu         sync.Mutex // serialises LearnDisposableMX
+	learnDisposable atomic.Bool
+	relearnPending  atomic.Bool
+}
+
+func New(opts Options) *Validator {
+	v := &Validator{resolver: opts.Resolver, timeouts: opts.Timeouts, smtp: opts.SMTP, mailAuth: opts.MailAuth, allowPrivateMX: opts.AllowPrivateMX}
+	if v.resolver == nil {
+		v.resolver = NewNetResolver("")
 	}
+	v.data.Store(newDataset(opts))
+	return v
+}
+
+var defaultValidator = New(DefaultOptions())
+
+// Default returns the package-level validator used by ValidateEmail.
+func Default() *Validator {
+	return defaultValidator
 }
 
-func LoadFreeProviders(url string) error {
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Get(url)
-	if err != nil {
-		return fmt.Errorf("failed to fetch free providers: %w", err)
+// update applies fn to a copy of the current dataset and publishes it.
+func (v *Validator) update(fn func(d *dataset)) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	d := v.data.Load().clone()
+	fn(d)
+	v.data.Store(d)
+}
+
+func (v *Validator) SetOverrides(corporate []string, personal []string) {
+	v.update(func(d *dataset) {
+		d.setList(ListCorporateOverride, SourceBuiltin, listOf(corporate))
+		d.setList(ListPersonalOverride, SourceBuiltin, listOf(personal))
+	})
+}
+
+// SetList adds a list source, or replaces the entries of an existing source
+// with the same kind and name.
+func (v *Validator) SetList(l DomainList) {
+	v.update(func(d *dataset) {
+		d.setList(l.Kind, l.Source, l.Domains)
+	})
+	if l.Kind == ListDisposable {
+		v.relearnDisposableMX()
 	}
-	defer resp.Body.Close()
+}
+
+func SetOverrides(corporate []string, personal []string) {
+	defaultValidator.SetOverrides(corporate, personal)
+}
+
+func ValidateEmail(email string) *ValidationResult {
+	return defaultValidator.Validate(email)
+}
+
+func (v *Validator) Validate(email string) *ValidationResult {
+	return v.ValidateContext(context.Background(), email)
+}
 
-	var providers []string
-	if err := json.NewDecoder(resp.Body).Decode(&providers); err != nil {
-		return fmt.Errorf("failed to decode free providers: %w", err)
+// ValidateContext runs the validation pipeline, stopping network work when
+// ctx is done. Stages that exceed their budget are listed in TimedOut.
+func (v *Validator) ValidateContext(ctx context.Context, email string) *ValidationResult {
+	return v.validate(ctx, email, v.resolver, CheckOptions{})
+}
+
+// Check is ValidateContext with per-request options.
+func (v *Validator) Check(ctx context.Context, email string, opts CheckOptions) *ValidationResult {
+	return v.validate(ctx, email, v.resolver, opts)
+}
+
+// ApplyVerdict lets an external classifier such as the AI check set
+// provider_type ("corporate" or "personal") under rule. Only results left
+// unclassified or classified by the MX heuristic are changed; list matches
+// and the disposable heuristic take precedence. It reports whether the
+// verdict was applied and recomputes the risk score either way.
+func (v *Validator) ApplyVerdict(r *ValidationResult, opts CheckOptions, rule, providerType string, confidence float64) bool {
+	applied := false
+	if (r.ClassifiedBy == "" || r.ClassifiedBy == RuleMXHeuristic) && (providerType == "corporate" || providerType == "personal") {
+		r.ProviderType = providerType
+		r.IsCorporate = providerType == "corporate"
+		r.IsPersonal = providerType == "personal"
+		r.IsDisposable = false
+		r.ClassifiedBy = rule
+		r.Confidence = min(max(confidence, 0), 1)
+		r.ListSource = ""
+		r.Valid = resultValid(r, opts)
+		applied = true
 	}
+	v.Score(r)
+	return applied
+}
 
-	for _, provider := range providers {
-		freeProviders[provider] = true
+func (v *Validator) validate(ctx context.Context, email string, resolver Resolver, opts CheckOptions) *ValidationResult {
+	data := v.data.Load()
+	tr := newTrace(opts.Debug)
+	if tr != nil {
+		resolver = &tracingResolver{next: resolver, trace: tr}
 	}
+	result := v.run(ctx, data, email, resolver, opts, tr)
 
-	return nil
+	start := time.Now()
+	tr.add(start, TraceStep{Stage: "verdict", Rule: result.ClassifiedBy, ListSource: result.ListSource,
+		Outcome: "valid=" + strconv.FormatBool(result.Valid) + " type=" + result.ProviderType + ": " + result.Message})
+	start = time.Now()
+	data.score(result)
+	tr.add(start, TraceStep{Stage: "score", Answers: result.Reasons, Outcome: strconv.Itoa(result.RiskScore)})
+	result.Trace = tr.result()
+	return result
 }
 
-func ValidateEmail(email string) *ValidationResult {
+// run is the validation pipeline. Every stage is recorded in tr, which is
+// nil unless debugging.
+func (v *Validator) run(ctx context.Context, data *dataset, email string, resolver Resolver, opts CheckOptions, tr *trace) *ValidationResult {
 	result := &ValidationResult{
 		Email:          email,
 		Valid:          false,
@@ -140,82 +201,276 @@ func ValidateEmail(email string) *ValidationResult {
 	}
 
 	// Step 1: Syntax validation
-	if !emailRegex.MatchString(email) {
-		result.Message = "Invalid email syntax"
+	mode := opts.Syntax
+	if mode == "" {
+		mode = SyntaxStrict
+	}
+	start := time.Now()
+	local, domain, serr := ParseAddress(email, mode)
+	if serr != nil {
+		result.SyntaxError = serr
+		result.Message = "Invalid email syntax: " + serr.Message
+		tr.add(start, TraceStep{Stage: "syntax", Input: email, Rule: string(mode), Outcome: serr.Error()})
 		return result
 	}
 	result.SyntaxValid = true
+	domain = strings.ToLower(domain)
+	result.DomainASCII = domain
+	result.DomainUnicode = domain
+	if u, err := idna.Lookup.ToUnicode(domain); err == nil {
+		result.DomainUnicode = u
+	}
+	result.SMTPUTF8 = !isASCII(local)
+	result.CanonicalEmail = data.normalizer.canonical(local, domain)
+	tr.add(start, TraceStep{Stage: "syntax", Input: email, Rule: string(mode), Outcome: "valid, domain " + domain})
 
-	// Extract domain
-	parts := strings.Split(email, "@")
-	if len(parts) != 2 {
-		result.Message = "Invalid email format"
-		return result
+	start = time.Now()
+	if category := data.roles.match(local); category != "" {
+		result.IsRole = true
+		result.RoleCategory = category
+		tr.add(start, TraceStep{Stage: "role", Input: local, Outcome: category})
+	} else {
+		tr.add(start, TraceStep{Stage: "role", Input: local, Outcome: "no match"})
+	}
+
+	if !strings.HasPrefix(domain, "[") {
+		result.RegistrableDomain, result.PublicSuffix = splitRegistrable(domain)
 	}
-	domain := strings.ToLower(parts[1])
 
-	// Step 2: Check if disposable
-	if disposableDomains[domain] {
+	// Step 2: List-based classification against the host, then its
+	// registrable domain
+	start = time.Now()
+	providerType, rule, listDomain := data.classifyHost(domain, result.RegistrableDomain)
+	result.ClassifiedBy = rule
+	result.ListSource = data.matchedSource(rule, listDomain)
+	if rule != "" {
+		tr.add(start, TraceStep{Stage: "classify", Input: domain, Rule: rule, ListSource: result.ListSource, Outcome: providerType + " via " + listDomain})
+	} else {
+		tr.add(start, TraceStep{Stage: "classify", Input: domain, Outcome: "no list match for " + domain + " or " + result.RegistrableDomain})
+	}
+	switch providerType {
+	case "disposable":
 		result.IsDisposable = true
 		result.ProviderType = "disposable"
 		result.Message = "Disposable email detected"
+		result.Confidence = classificationConfidence(rule, nil)
+		result.DisposableConfidence = 1
+		result.DisposableReason = "domain is on the disposable list"
 		return result
-	}
-
-	if freeProviders[domain] || knownPersonalDomains[domain] {
+	case "personal":
 		result.IsPersonal = true
 		result.ProviderType = "personal"
-		result.ProviderName = getProviderName(domain)
+		result.ProviderName = getProviderName(listDomain)
 		result.Message = "Personal email detected"
-	}
-
-	if corporateDomain, exists := corporateDomains[domain]; exists && !result.IsPersonal {
+	case "corporate":
 		result.IsCorporate = true
 		result.ProviderType = "corporate"
-		result.CorporateDomain = corporateDomain
-		if result.ProviderName == "" {
-			result.ProviderName = getProviderName(corporateDomain)
+		result.CorporateDomain = listDomain
+		if corporateDomain, exists := data.corporate[listDomain]; exists {
+			result.CorporateDomain = corporateDomain
 		}
-		if result.Message == "" {
-			result.Message = "Corporate email detected"
+		result.ProviderName = getProviderName(result.CorporateDomain)
+		result.Message = "Corporate email detected"
+	}
+	start = time.Now()
+	if c := data.company(listDomain, result.RegistrableDomain); c != nil {
+		tr.add(start, TraceStep{Stage: "company", Input: listDomain, Outcome: c.ID})
+		result.CompanyID = c.ID
+		result.ParentCompanyID = c.Parent
+		if result.IsCorporate && c.Name != "" {
+			result.ProviderName = c.Name
 		}
 	}
 
-	mxRecords, err := net.LookupMX(domain)
-	if err == nil && len(mxRecords) > 0 {
-		result.MXRecordsFound = true
+	if result.DomainUnicode != domain {
+		start = time.Now()
+		result.MixedScript = mixedScript(result.DomainUnicode)
+		if target := data.homographTarget(result.DomainUnicode); target != "" {
+			result.HomographOf = target
+			result.Message = "Domain imitates " + target
+		}
+		tr.add(start, TraceStep{Stage: "homograph", Input: result.DomainUnicode,
+			Outcome: "mixed_script=" + strconv.FormatBool(result.MixedScript) + " homograph_of=" + result.HomographOf})
+	}
+
+	var unconfirmedSuggestion string
+	if rule == "" && result.HomographOf == "" {
+		start = time.Now()
+		outcome := "none"
+		if suggestion, unconfirmed := data.suggestDomain(domain); suggestion != "" {
+			result.Suggestion = local + "@" + suggestion
+			outcome = suggestion
+			if unconfirmed {
+				unconfirmedSuggestion = suggestion
+				outcome += " unless the domain has its own mail hosts"
+			}
+		}
+		tr.add(start, TraceStep{Stage: "suggestion", Input: domain, Outcome: outcome})
+	}
+
+	// Step 3: DNS checks; domain literals (lenient mode) name the mail host
+	// directly
+	start = time.Now()
+	dnsCtx, cancel := withStageTimeout(ctx, v.timeouts.DNS)
+	var mxRecords []*net.MX
+	var err error
+	if strings.HasPrefix(domain, "[") {
 		result.DomainValid = true
+	} else if mxRecords, err = resolver.LookupMX(dnsCtx, domain); err == nil && len(mxRecords) > 0 {
+		result.MXRecordsFound = true
+		result.MXHosts = diagnoseMX(dnsCtx, resolver, mxRecords)
+		if errors.Is(dnsCtx.Err(), context.DeadlineExceeded) {
+			result.TimedOut = appendStage(result.TimedOut, StageDNS)
+		}
+		mxRecords = usableMX(mxRecords, result.MXHosts, v.allowPrivateMX)
+		if isNullMX(result.MXHosts) {
+			result.NullMX = true
+			result.Message = "Domain does not accept email (null MX)"
+		} else if len(mxRecords) > 0 {
+			result.DomainValid = true
+		} else {
+			result.Message = "No MX host can receive email"
+		}
+	} else if isTimeout(err) {
+		result.TimedOut = appendStage(result.TimedOut, StageDNS)
+		result.Message = "DNS lookup timed out"
 	} else {
-		hosts, herr := net.LookupHost(domain)
+		hosts, herr := resolver.LookupHost(dnsCtx, domain)
 		if herr == nil && len(hosts) > 0 {
 			result.DomainValid = true
+		} else if isTimeout(herr) {
+			result.TimedOut = appendStage(result.TimedOut, StageDNS)
+			result.Message = "DNS lookup timed out"
 		} else {
 			result.Message = "Domain has no MX records"
 		}
 	}
+	cancel()
+	if tr != nil {
+		hosts := make([]string, len(result.MXHosts))
+		for i, h := range result.MXHosts {
+			hosts[i] = strconv.Itoa(int(h.Pref)) + " " + h.Host + " " + h.Status
+		}
+		tr.add(start, TraceStep{Stage: "mx", Input: domain, Answers: hosts, Outcome: "domain_valid=" + strconv.FormatBool(result.DomainValid) + " " + result.Message})
+	}
+	// A look-alike with mail hosts of its own (or that might have them) is
+	// a real domain; one whose mail goes to the suggested domain's owner,
+	// or that only has an A record, is still taken for a typo
+	if unconfirmedSuggestion != "" && (len(result.TimedOut) > 0 || !mxOwnedBy(mxRecords, unconfirmedSuggestion)) {
+		result.Suggestion = ""
+	}
 
-	// Step 6: Determine provider name from MX records
-	if result.ProviderName == "" && len(mxRecords) > 0 {
-		result.ProviderName = getProviderFromMX(mxRecords)
+	// Step 4: Identify the mail host and any gateway in front of it from
+	// the MX targets, SPF includes and verification records
+	if len(mxRecords) > 0 {
+		start = time.Now()
+		fpCtx, cancel := withStageTimeout(ctx, v.timeouts.DNS)
+		txts, err := lookupTXT(fpCtx, resolver, domain)
+		if isTimeout(err) {
+			result.TimedOut = appendStage(result.TimedOut, StageDNS)
+		}
+		cancel()
+		var includes []string
+		for _, txt := range txts {
+			if lower := strings.ToLower(txt); lower == "v=spf1" || strings.HasPrefix(lower, "v=spf1 ") {
+				includes = append(includes, spfIncludes(txt)...)
+			}
+		}
+		hosting, gateway := data.prints.identify(mxRecords, includes, txts)
+		if hosting != nil {
+			result.HostingProvider = hosting.Name
+			if result.ProviderName == "" {
+				result.ProviderName = hosting.Name
+			}
+		}
+		if gateway != nil {
+			result.Gateway = gateway.Name
+		}
+		tr.add(start, TraceStep{Stage: "fingerprint", Input: domain, Outcome: "hosting=" + result.HostingProvider + " gateway=" + result.Gateway})
 	}
-	if result.ProviderName == "" || strings.EqualFold(result.ProviderName, "Unknown") {
+	if result.ProviderName == "" {
 		result.ProviderName = domain
 	}
 
-	// Step 7: Final classification
-	if result.ProviderType == "unknown" {
-		if result.IsPersonal {
-			result.ProviderType = "personal"
-		} else if !freeProviders[domain] && !knownPersonalDomains[domain] && !result.IsDisposable && result.DomainValid {
-			result.ProviderType = "corporate"
-			result.IsCorporate = true
-		} else {
-			result.ProviderType = "personal"
-			result.IsPersonal = true
+	// Step 5: Optional sender authentication posture
+	if v.mailAuth != nil && result.MXRecordsFound {
+		start = time.Now()
+		authCtx, cancel := withStageTimeout(ctx, v.timeouts.DNS)
+		result.MailAuth = checkMailAuth(authCtx, resolver, domain, result.RegistrableDomain, v.mailAuth)
+		if errors.Is(authCtx.Err(), context.DeadlineExceeded) {
+			result.TimedOut = appendStage(result.TimedOut, StageDNS)
 		}
+		cancel()
+		tr.add(start, TraceStep{Stage: "mail_auth", Input: domain,
+			Outcome: "spf=" + strconv.FormatBool(result.MailAuth.SPF.Found) + " dmarc=" + result.MailAuth.DMARC.Policy})
 	}
 
-	result.Valid = result.SyntaxValid && result.DomainValid && !result.IsDisposable
+	// Step 6: Optional mailbox probe
+	// Only hosts whose addresses were checked are probed, and the probe dials
+	// those addresses, so a hostile zone cannot point it at internal hosts
+	if probe := probeableMX(result.MXHosts, v.allowPrivateMX); v.smtp != nil && len(mxRecords) > 0 && len(probe) > 0 {
+		start = time.Now()
+		smtpCtx, cancel := withStageTimeout(ctx, v.timeouts.SMTP)
+		result.SMTP = v.smtp.Probe(smtpCtx, probe, local+"@"+domain)
+		tr.add(start, TraceStep{Stage: "smtp", Input: result.SMTP.MXHost,
+			Outcome: result.SMTP.Status + " " + strconv.Itoa(result.SMTP.Code) + " " + result.SMTP.Message})
+		if errors.Is(smtpCtx.Err(), context.DeadlineExceeded) {
+			result.TimedOut = appendStage(result.TimedOut, StageSMTP)
+		}
+		cancel()
+		result.IsCatchAll = result.SMTP.CatchAll
+		if result.SMTP.Status == SMTPUndeliverable {
+			result.Message = "Mailbox does not exist"
+		}
+	}
+
+	// Step 7: Disposable heuristics for a resolvable domain that matched no
+	// list; a likely throwaway domain is reported as disposable, but
+	// is_disposable stays reserved for list matches
+	if result.ClassifiedBy == "" && result.DomainValid && !strings.HasPrefix(domain, "[") {
+		start = time.Now()
+		heurCtx, cancel := withStageTimeout(ctx, v.timeouts.DNS)
+		check := data.checkDisposable(heurCtx, resolver, domain, result.RegistrableDomain, mxRecords, result.IsCatchAll)
+		if errors.Is(heurCtx.Err(), context.DeadlineExceeded) {
+			result.TimedOut = appendStage(result.TimedOut, StageDNS)
+		}
+		cancel()
+		result.DisposableConfidence = check.confidence
+		result.DisposableReason = check.reason()
+		if len(check.signals) > 0 {
+			result.DisposableSignals = check.codes()
+		}
+		outcome := "confidence=" + strconv.FormatFloat(check.confidence, 'f', -1, 64)
+		rule := ""
+		if check.flagged() {
+			result.ProviderType = "disposable"
+			result.ClassifiedBy = RuleDisposableHeuristic
+			rule = RuleDisposableHeuristic
+			if result.Message == "" {
+				result.Message = "Likely disposable: " + result.DisposableReason
+			}
+			outcome += " flagged"
+		}
+		tr.add(start, TraceStep{Stage: "disposable_heuristic", Input: domain, Rule: rule, Answers: result.DisposableSignals, Outcome: outcome})
+	}
+
+	// Step 8: MX heuristic -- a resolvable domain that matched no list and
+	// shows no sign of being disposable is treated as a company domain
+	if result.ClassifiedBy == "" && result.DomainValid {
+		result.ProviderType = "corporate"
+		result.IsCorporate = true
+		result.ClassifiedBy = RuleMXHeuristic
+		tr.add(time.Now(), TraceStep{Stage: "mx_heuristic", Input: domain, Rule: RuleMXHeuristic, Outcome: "corporate"})
+	}
+	result.Confidence = classificationConfidence(result.ClassifiedBy, result.MailAuth)
+	if result.ClassifiedBy == RuleDisposableHeuristic {
+		result.Confidence = result.DisposableConfidence
+	}
+
+	result.Valid = resultValid(result, opts)
+	if opts.RejectRole && result.IsRole {
+		result.Message = "Role-based address (" + result.RoleCategory + ")"
+	}
 	if result.Message == "" {
 		if result.IsCorporate {
 			result.Message = "Corporate email detected"
@@ -229,6 +484,42 @@ func ValidateEmail(email string) *ValidationResult {
 	return result
 }
 
+// resultValid derives the valid flag from a classified result.
+func resultValid(r *ValidationResult, opts CheckOptions) bool {
+	return r.SyntaxValid && r.DomainValid && r.ProviderType != "disposable" &&
+		(r.SMTP == nil || r.SMTP.Status != SMTPUndeliverable) &&
+		!(opts.RejectRole && r.IsRole)
+}
+
+func appendStage(stages []string, stage string) []string {
+	for _, s := range stages {
+		if s == stage {
+			return stages
+		}
+	}
+	return append(stages, stage)
+}
+
+func withStageTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
+}
+
+// isTimeout reports whether err was caused by a deadline rather than a
+// definitive negative answer.
+func isTimeout(err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
+
 func getProviderName(domain string) string {
 	providerMap := map[string]string{
 		"google.com":     "Google",
@@ -274,28 +565,3 @@ func getProviderName(domain string) string {
 	}
 	return domain
 }
-
-func getProviderFromMX(mxRecords []*net.MX) string {
-	for _, mx := range mxRecords {
-		host := strings.ToLower(mx.Host)
-
-		if strings.Contains(host, "google") || strings.Contains(host, "gmail") {
-			return "Google"
-		}
-		if strings.Contains(host, "outlook") || strings.Contains(host, "hotmail") ||
-			strings.Contains(host, "live") || strings.Contains(host, "microsoft") {
-			return "Microsoft"
-		}
-		if strings.Contains(host, "yahoo") {
-			return "Yahoo"
-		}
-		if strings.Contains(host, "yandex") {
-			return "Yandex"
-		}
-		if strings.Contains(host, "apple") || strings.Contains(host, "icloud") {
-			return "Apple"
-		}
-	}
-
-	return "Unknown"
-}
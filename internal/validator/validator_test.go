@@ -0,0 +1,446 @@
+package validator
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestClassifyPrecedence(t *testing.T) {
+	opts := DefaultOptions()
+	opts.CorporateDomains["free-and-corporate.example"] = "free-and-corporate.example"
+	opts.FreeProviders = []string{"free-and-corporate.example"}
+	opts.CorporateOverrides = []string{"gmail.com", "Corp-Override.example"}
+	opts.PersonalOverrides = []string{"ya.ru", "google.com", "mailinator.com"}
+	opts.Resolver = NewFakeResolver().
+		AddMX("resolves.example", "mx.resolves.example.", 10).
+		AddHost("mx.resolves.example", "64.233.184.26")
+	v := New(opts)
+
+	tests := []struct {
+		domain       string
+		providerType string
+		rule         string
+	}{
+		{"gmail.com", "corporate", RuleOverrideCorporate},
+		{"corp-override.example", "corporate", RuleOverrideCorporate},
+		{"ya.ru", "personal", RuleOverridePersonal},
+		{"google.com", "personal", RuleOverridePersonal},
+		{"mailinator.com", "personal", RuleOverridePersonal},
+		{"yopmail.com", "disposable", RuleDisposableList},
+		{"microsoft.com", "corporate", RuleCorporateMap},
+		{"free-and-corporate.example", "corporate", RuleCorporateMap},
+		{"outlook.com", "personal", RuleFreeList},
+		{"resolves.example", "corporate", RuleMXHeuristic},
+		// Unlike the original service, a domain that matches no list and
+		// does not resolve is no longer reported as personal
+		{"unlisted.example", "unknown", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.domain, func(t *testing.T) {
+			r := v.Validate("user@" + tt.domain)
+			if r.ProviderType != tt.providerType || r.ClassifiedBy != tt.rule {
+				t.Errorf("%s: provider_type %q classified_by %q, want %q %q", tt.domain, r.ProviderType, r.ClassifiedBy, tt.providerType, tt.rule)
+			}
+			if r.IsPersonal != (tt.providerType == "personal") || r.IsCorporate != (tt.providerType == "corporate") {
+				t.Errorf("%s: is_personal=%v is_corporate=%v for provider_type %q", tt.domain, r.IsPersonal, r.IsCorporate, tt.providerType)
+			}
+		})
+	}
+}
+
+func TestValidatorsAreIndependent(t *testing.T) {
+	a := New(DefaultOptions())
+	b := New(DefaultOptions())
+	a.SetOverrides(nil, []string{"microsoft.com"})
+
+	if got, _ := a.data.Load().classify("microsoft.com"); got != "personal" {
+		t.Errorf("a: microsoft.com classified as %q, want personal", got)
+	}
+	if got, _ := b.data.Load().classify("microsoft.com"); got != "corporate" {
+		t.Errorf("b: microsoft.com classified as %q, want corporate", got)
+	}
+}
+
+func TestValidateWithFakeResolver(t *testing.T) {
+	res := NewFakeResolver().
+		AddMX("gmail.com", "gmail-smtp-in.l.google.com.", 5).
+		AddMX("acme.example", "aspmx.l.google.com.", 1).
+		AddHost("gmail-smtp-in.l.google.com", "142.250.27.26").
+		AddHost("aspmx.l.google.com", "142.250.27.27").
+		AddHost("a-only.example", "192.0.2.10")
+
+	opts := DefaultOptions()
+	opts.Resolver = res
+	v := New(opts)
+
+	tests := []struct {
+		email        string
+		valid        bool
+		providerType string
+		providerName string
+		rule         string
+	}{
+		{"john@gmail.com", true, "personal", "Gmail", RuleFreeList},
+		{"jane@acme.example", true, "corporate", "Google Workspace", RuleMXHeuristic},
+		{"ops@a-only.example", true, "corporate", "a-only.example", RuleMXHeuristic},
+		{"bob@missing.example", false, "unknown", "missing.example", ""},
+		{"eve@yopmail.com", false, "disposable", "", RuleDisposableList},
+		{"not-an-email", false, "unknown", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.email, func(t *testing.T) {
+			r := v.Validate(tt.email)
+			if r.Valid != tt.valid || r.ProviderType != tt.providerType || r.ProviderName != tt.providerName || r.ClassifiedBy != tt.rule {
+				t.Errorf("Validate(%q) = valid=%v type=%q name=%q rule=%q, want valid=%v type=%q name=%q rule=%q",
+					tt.email, r.Valid, r.ProviderType, r.ProviderName, r.ClassifiedBy,
+					tt.valid, tt.providerType, tt.providerName, tt.rule)
+			}
+		})
+	}
+}
+
+type blockingResolver struct{}
+
+func (blockingResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingResolver) LookupHost(ctx context.Context, name string) ([]string, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func (blockingResolver) LookupTXT(ctx context.Context, name string) ([]string, error) {
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestValidateDNSTimeout(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Resolver = blockingResolver{}
+	opts.Timeouts.DNS = 10 * time.Millisecond
+	r := New(opts).Validate("jane@slow.example")
+
+	if len(r.TimedOut) != 1 || r.TimedOut[0] != StageDNS {
+		t.Fatalf("TimedOut = %v, want [%s]", r.TimedOut, StageDNS)
+	}
+	if r.DomainValid {
+		t.Error("DomainValid = true after DNS timeout")
+	}
+}
+
+func TestValidateBatchOrderAndDedup(t *testing.T) {
+	upstream := &countingResolver{FakeResolver: NewFakeResolver().
+		AddMX("gmail.com", "gmail-smtp-in.l.google.com.", 5).
+		AddMX("acme.example", "mx.acme.example.", 10).
+		AddHost("gmail-smtp-in.l.google.com", "142.250.27.26").
+		AddHost("mx.acme.example", "64.233.184.26")}
+	opts := DefaultOptions()
+	opts.Resolver = upstream
+	v := New(opts)
+
+	emails := []string{"a@gmail.com", "b@acme.example", "c@gmail.com", "bad", "d@acme.example", "e@gmail.com"}
+	results := v.ValidateBatch(context.Background(), emails, 3, CheckOptions{})
+
+	for i, r := range results {
+		if r.Email != emails[i] {
+			t.Errorf("results[%d].Email = %q, want %q", i, r.Email, emails[i])
+		}
+	}
+	if results[1].ProviderType != "corporate" || results[3].SyntaxValid {
+		t.Errorf("unexpected classification: %+v, %+v", results[1], results[3])
+	}
+	if got := upstream.mxCalls.Load(); got != 2 {
+		t.Errorf("upstream MX calls = %d, want 2 (one per domain)", got)
+	}
+}
+
+func TestValidateBatchCancelled(t *testing.T) {
+	upstream := &countingResolver{FakeResolver: NewFakeResolver()}
+	opts := DefaultOptions()
+	opts.Resolver = upstream
+	v := New(opts)
+
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancel := context.WithDeadline(context.Background(), time.Now())
+	defer cancel()
+	for _, tt := range []struct {
+		ctx      context.Context
+		timedOut bool
+	}{
+		{cancelled, false},
+		{expired, true},
+	} {
+		emails := []string{"a@acme.example", "b@acme.example", "c@acme.example"}
+		results := v.ValidateBatch(tt.ctx, emails, 2, CheckOptions{})
+		for i, r := range results {
+			if r == nil || r.Email != emails[i] || r.Valid || !strings.HasPrefix(r.Message, "Not checked") {
+				t.Fatalf("%v: results[%d] = %+v", tt.ctx.Err(), i, r)
+			}
+			if (len(r.TimedOut) > 0) != tt.timedOut {
+				t.Errorf("%v: timed_out %v", tt.ctx.Err(), r.TimedOut)
+			}
+		}
+	}
+	if got := upstream.mxCalls.Load(); got != 0 {
+		t.Errorf("upstream MX calls = %d after cancellation, want 0", got)
+	}
+}
+
+func TestRoleAccounts(t *testing.T) {
+	opts := DefaultOptions()
+	opts.RoleAccounts["ventas-eu"] = RoleSales
+	opts.Resolver = NewFakeResolver().
+		AddMX("acme.example", "mx.acme.example.", 10).
+		AddHost("mx.acme.example", "64.233.184.26")
+	v := New(opts)
+
+	tests := []struct {
+		local    string
+		category string
+	}{
+		{"info", RoleGeneral},
+		{"Sales", RoleSales},
+		{"no-reply", RoleNoReply},
+		{"noreply.billing", RoleNoReply},
+		{"do_not_reply", RoleNoReply},
+		{"support+tickets", RoleSupport},
+		{"kontakt", RoleGeneral},
+		{"ventas.eu", RoleSales},
+		{"jane.doe", ""},
+		{"information", ""},
+	}
+	for _, tt := range tests {
+		r := v.Validate(tt.local + "@acme.example")
+		if r.IsRole != (tt.category != "") || r.RoleCategory != tt.category {
+			t.Errorf("%s: is_role=%v category=%q, want %q", tt.local, r.IsRole, r.RoleCategory, tt.category)
+		}
+	}
+
+	if r := v.Check(context.Background(), "info@acme.example", CheckOptions{RejectRole: true}); r.Valid {
+		t.Error("role account valid with RejectRole")
+	}
+	if r := v.Check(context.Background(), "jane@acme.example", CheckOptions{RejectRole: true}); !r.Valid {
+		t.Error("personal mailbox invalid with RejectRole")
+	}
+}
+
+func TestDomainSuggestions(t *testing.T) {
+	opts := DefaultOptions()
+	opts.FreeProviders = []string{"fastmail.com", "gmx.de"}
+	opts.Resolver = NewFakeResolver().
+		AddMX("gmial.com", "mx.parked.example.", 10).
+		AddMX("apple.co", "mx.apple.co.", 10).
+		AddMX("netflix.co", "mx.netflix.co.", 10).
+		AddMX("amazn.com", "mx1.amazon.com.", 10).
+		AddMX("fastmali.com", "mx.fastmali.com.", 10).
+		AddHost("gogle.com", "216.58.212.36").
+		AddHost("mx.parked.example", "64.233.184.27").
+		AddHost("mx1.amazon.com", "52.119.213.150").
+		AddHost("mx.fastmali.com", "64.233.184.30").
+		AddHost("mx.apple.co", "17.142.163.1").
+		AddHost("mx.netflix.co", "64.233.184.29")
+	v := New(opts)
+
+	tests := []struct {
+		email string
+		want  string
+	}{
+		// Real company domains that receive mail are not typos
+		{"tim@apple.co", ""},
+		{"reed@netflix.co", ""},
+		{"ann@fastmali.com", ""},
+		// Resolving, or sending mail to the company, does not make a typo real
+		{"john@gogle.com", "john@google.com"},
+		{"jeff@amazn.com", "jeff@amazon.com"},
+		{"tim@aple.com", "tim@apple.com"},
+		{"john@gmial.com", "john@gmail.com"},
+		{"john@outlok.com", "john@outlook.com"},
+		{"john@gmail.con", "john@gmail.com"},
+		{"john@hotmial.com", "john@hotmail.com"},
+		{"john@fastmial.com", "john@fastmail.com"},
+		{"john@acme.con", "john@acme.com"},
+		{"john@gmail.com", ""},
+		{"john@acme-industries.com", ""},
+	}
+	for _, tt := range tests {
+		if got := v.Validate(tt.email).Suggestion; got != tt.want {
+			t.Errorf("%s: suggestion %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestInternationalizedAddresses(t *testing.T) {
+	opts := DefaultOptions()
+	opts.Resolver = NewFakeResolver().
+		AddMX("xn--e1afmkfd.xn--p1ai", "mx.example.ru.", 10).
+		AddMX("xn--gmil-63d.com", "mx.phish.example.", 10).
+		AddHost("mx.example.ru", "77.88.21.249").
+		AddHost("mx.phish.example", "64.233.184.28")
+	v := New(opts)
+
+	res := v.Validate("иван@пример.рф")
+	if !res.Valid || !res.SMTPUTF8 || res.DomainASCII != "xn--e1afmkfd.xn--p1ai" || res.DomainUnicode != "пример.рф" {
+		t.Errorf("пример.рф: %+v", res)
+	}
+	if res.MixedScript || res.HomographOf != "" {
+		t.Errorf("пример.рф flagged as homograph: %+v", res)
+	}
+
+	// Cyrillic "а" in place of the Latin one
+	res = v.Validate("john@gmаil.com")
+	if res.HomographOf != "gmail.com" || !res.MixedScript || res.SMTPUTF8 {
+		t.Errorf("gmаil.com: homograph_of %q, mixed_script %v, smtputf8 %v", res.HomographOf, res.MixedScript, res.SMTPUTF8)
+	}
+	if res.Suggestion != "" {
+		t.Errorf("gmаil.com: unexpected suggestion %q", res.Suggestion)
+	}
+}
+
+func TestCanonicalEmail(t *testing.T) {
+	v := New(DefaultOptions())
+	tests := []struct{ email, want string }{
+		{"John.Doe+promo@gmail.com", "johndoe@gmail.com"},
+		{"johndoe@googlemail.com", "johndoe@gmail.com"},
+		{"j.o.h.n.d.o.e@GMail.com", "johndoe@gmail.com"},
+		{"ivan+news@ya.ru", "ivan@yandex.ru"},
+		{"jane-lists@yahoo.com", "jane@yahoo.com"},
+		{"jane+lists@outlook.com", "jane@outlook.com"},
+		{"John.Doe+x@Acme.com", "John.Doe+x@acme.com"},
+		{`"john+doe"@gmail.com`, `"john+doe"@gmail.com`},
+	}
+	for _, tt := range tests {
+		if got := v.Validate(tt.email).CanonicalEmail; got != tt.want {
+			t.Errorf("%s: canonical %q, want %q", tt.email, got, tt.want)
+		}
+	}
+}
+
+func TestRegistrableDomainMatching(t *testing.T) {
+	opts := DefaultOptions()
+	opts.CorporateDomains["bbc.co.uk"] = "bbc.co.uk"
+	opts.Resolver = NewFakeResolver().
+		AddMX("eng.google.com", "aspmx.l.google.com.", 10).
+		AddMX("mail.corp.amazon.com", "mx.amazon.com.", 10).
+		AddMX("news.bbc.co.uk", "mx.bbc.co.uk.", 10).
+		AddMX("inbox.yopmail.com", "mx.yopmail.com.", 10).
+		AddMX("smith.github.io", "mx.example.", 10).
+		AddHost("aspmx.l.google.com", "142.250.27.27").
+		AddHost("mx.amazon.com", "52.94.124.9").
+		AddHost("mx.bbc.co.uk", "212.58.237.1").
+		AddHost("mx.yopmail.com", "87.98.164.155").
+		AddHost("mx.example", "64.233.184.29")
+	v := New(opts)
+
+	tests := []struct {
+		email           string
+		providerType    string
+		corporateDomain string
+		registrable     string
+		suffix          string
+	}{
+		{"a@eng.google.com", "corporate", "google.com", "google.com", "com"},
+		{"a@mail.corp.amazon.com", "corporate", "amazon.com", "amazon.com", "com"},
+		{"a@news.bbc.co.uk", "corporate", "bbc.co.uk", "bbc.co.uk", "co.uk"},
+		{"a@inbox.yopmail.com", "disposable", "", "yopmail.com", "com"},
+		{"a@smith.github.io", "corporate", "", "smith.github.io", "github.io"},
+	}
+	for _, tt := range tests {
+		res := v.Validate(tt.email)
+		if res.ProviderType != tt.providerType || res.CorporateDomain != tt.corporateDomain ||
+			res.RegistrableDomain != tt.registrable || res.PublicSuffix != tt.suffix {
+			t.Errorf("%s: got (%q, %q, %q, %q), want (%q, %q, %q, %q)", tt.email,
+				res.ProviderType, res.CorporateDomain, res.RegistrableDomain, res.PublicSuffix,
+				tt.providerType, tt.corporateDomain, tt.registrable, tt.suffix)
+		}
+	}
+}
+
+func TestCompanyGraph(t *testing.T) {
+	opts := DefaultOptions()
+	companies, err := LoadCompanies(strings.NewReader(`[
+		{"id": "acme", "name": "Acme Corp", "employee_domains": ["acme.example", "acme-labs.example"]},
+		{"id": "acme-shop", "name": "Acme Shop", "parent": "acme", "employee_domains": ["acmeshop.example"], "brand_domains": ["acmemail.example"]}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	opts.Companies = append(opts.Companies, companies...)
+	opts.PersonalDomains = append(opts.PersonalDomains, "acmemail.example")
+	opts.Resolver = NewFakeResolver()
+	v := New(opts)
+
+	tests := []struct {
+		email, providerType, corporateDomain, providerName, companyID, parent string
+	}{
+		{"a@fb.com", "corporate", "meta.com", "Meta", "meta", ""},
+		{"a@instagram.com", "corporate", "meta.com", "Meta", "meta", ""},
+		{"a@yandex-team.com", "corporate", "yandex-team.ru", "Yandex", "yandex", ""},
+		{"a@yandex.ru", "personal", "", "Yandex", "yandex", ""},
+		{"a@github.com", "corporate", "github.com", "GitHub", "github", "microsoft"},
+		{"a@acme-labs.example", "corporate", "acme.example", "Acme Corp", "acme", ""},
+		{"a@acmemail.example", "personal", "", "Acmemail.example", "acme-shop", "acme"},
+	}
+	for _, tt := range tests {
+		res := v.Validate(tt.email)
+		if res.ProviderType != tt.providerType || res.CorporateDomain != tt.corporateDomain ||
+			res.ProviderName != tt.providerName || res.CompanyID != tt.companyID || res.ParentCompanyID != tt.parent {
+			t.Errorf("%s: got (%q, %q, %q, %q, %q)", tt.email, res.ProviderType, res.CorporateDomain,
+				res.ProviderName, res.CompanyID, res.ParentCompanyID)
+		}
+	}
+
+	if _, err := LoadCompanies(strings.NewReader(`[{"name": "No ID"}]`)); err == nil {
+		t.Error("LoadCompanies accepted a company without id")
+	}
+}
+
+// Brand domains are consumer mailboxes; without a personal entry the MX
+// heuristic would report them as the company's corporate domains.
+func TestBuiltinBrandDomainsArePersonal(t *testing.T) {
+	resolver := NewFakeResolver().AddHost("mx.brand.example", "64.233.184.26")
+	var domains []string
+	for _, c := range DefaultCompanies() {
+		for _, d := range c.BrandDomains {
+			resolver.AddMX(d, "mx.brand.example.", 10)
+			domains = append(domains, d)
+		}
+	}
+	opts := DefaultOptions()
+	opts.Resolver = resolver
+	v := New(opts)
+	for _, d := range domains {
+		if res := v.Validate("jane@" + d); res.ProviderType != "personal" || res.CompanyID == "" {
+			t.Errorf("%s: provider_type %q company_id %q, want personal with a company", d, res.ProviderType, res.CompanyID)
+		}
+	}
+}
+
+func TestLoadNormalizationRules(t *testing.T) {
+	rules, err := LoadNormalizationRules(strings.NewReader(`[{"domains": ["mail.example", "alias.example"], "tag_separator": "+", "ignore_dots": true}]`))
+	if err != nil || len(rules) != 1 {
+		t.Fatalf("LoadNormalizationRules = %+v, %v", rules, err)
+	}
+	opts := DefaultOptions()
+	opts.Normalization = append(opts.Normalization, rules...)
+	if got := New(opts).Validate("J.Doe+x@alias.example").CanonicalEmail; got != "jdoe@mail.example" {
+		t.Errorf("canonical %q, want jdoe@mail.example", got)
+	}
+	for _, in := range []string{
+		`[{"domains": []}]`,
+		`[{"domains": ["mail.example", " "]}]`,
+		`[{"domains": ["mail.example"], "tag_separator": "++"}]`,
+		`{"domains": ["mail.example"]}`,
+	} {
+		if _, err := LoadNormalizationRules(strings.NewReader(in)); err == nil {
+			t.Errorf("LoadNormalizationRules(%s) succeeded", in)
+		}
+	}
+}
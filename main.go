@@ -24,7 +24,7 @@ func main() {
 		Addr:         ":" + port,
 		Handler:      router,
 		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
+		WriteTimeout: api.WriteTimeout,
 		IdleTimeout:  60 * time.Second,
 	}
 